@@ -0,0 +1,103 @@
+// Package broker provides an actor delivering messages published to named topics to the actors subscribed to them.
+package broker
+
+import (
+	"context"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/meschbach/go-junk-bucket/pkg/emitter"
+)
+
+type subscriptionKey struct {
+	pattern    string
+	subscriber actors.Pid
+}
+
+// runtimeKey carries the runtime of the publishing tick to the listeners of the dispatcher.
+type runtimeKey struct{}
+
+// Broker delivers published messages to subscribers in the order they subscribed.  Subscribers are monitored and
+// dropped once they exit.
+type Broker struct {
+	dispatcher    *emitter.Dispatcher[Published]
+	subscriptions map[subscriptionKey]*emitter.Subscription[Published]
+	//patterns are the patterns subscribed to by each subscriber
+	patterns map[actors.Pid]map[string]struct{}
+}
+
+// NewBroker creates a broker without subscribers.
+func NewBroker() *Broker {
+	return &Broker{
+		dispatcher:    emitter.NewDispatcher[Published](),
+		subscriptions: make(map[subscriptionKey]*emitter.Subscription[Published]),
+		patterns:      make(map[actors.Pid]map[string]struct{}),
+	}
+}
+
+func (b *Broker) OnMessage(bif actors.Runtime, m any) {
+	switch msg := m.(type) {
+	case *actors.Start:
+	case actors.Stopping:
+	case Subscribe:
+		b.subscribe(bif, msg)
+	case Unsubscribe:
+		b.unsubscribe(bif, msg)
+	case Publish:
+		b.publish(bif, msg)
+	case actors.NormalExit:
+		b.drop(msg.Who)
+	case actors.PanicExit:
+		b.drop(msg.Who)
+	default:
+		bif.Log().Warn("Unknown broker message: %#v", msg)
+	}
+}
+
+func (b *Broker) subscribe(bif actors.Runtime, msg Subscribe) {
+	key := subscriptionKey{pattern: msg.Pattern, subscriber: msg.Subscriber}
+	if _, has := b.subscriptions[key]; has {
+		return
+	}
+	if _, has := b.patterns[msg.Subscriber]; !has {
+		b.patterns[msg.Subscriber] = make(map[string]struct{})
+		bif.Monitor2(msg.Subscriber, bif.Self())
+	}
+	b.patterns[msg.Subscriber][msg.Pattern] = struct{}{}
+	b.subscriptions[key] = b.dispatcher.On(func(ctx context.Context, event Published) {
+		if Matches(msg.Pattern, event.Topic) {
+			ctx.Value(runtimeKey{}).(actors.Runtime).Tell(msg.Subscriber, event)
+		}
+	})
+}
+
+func (b *Broker) unsubscribe(bif actors.Runtime, msg Unsubscribe) {
+	key := subscriptionKey{pattern: msg.Pattern, subscriber: msg.Subscriber}
+	sub, has := b.subscriptions[key]
+	if !has {
+		return
+	}
+	sub.Off()
+	delete(b.subscriptions, key)
+	delete(b.patterns[msg.Subscriber], msg.Pattern)
+	if len(b.patterns[msg.Subscriber]) == 0 {
+		delete(b.patterns, msg.Subscriber)
+		bif.Unmonitor(msg.Subscriber, bif.Self())
+	}
+}
+
+func (b *Broker) publish(bif actors.Runtime, msg Publish) {
+	ctx := context.WithValue(bif.Context(), runtimeKey{}, bif)
+	if err := b.dispatcher.Emit(ctx, Published{Topic: msg.Topic, Message: msg.Message}); err != nil {
+		bif.Log().Warn("Failed to deliver %q: %s", msg.Topic, err.Error())
+	}
+}
+
+// drop removes all subscriptions of an exited subscriber.
+func (b *Broker) drop(who actors.Pid) {
+	for pattern := range b.patterns[who] {
+		key := subscriptionKey{pattern: pattern, subscriber: who}
+		b.subscriptions[key].Off()
+		delete(b.subscriptions, key)
+	}
+	delete(b.patterns, who)
+}
@@ -0,0 +1,78 @@
+package broker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/meschbach/go-junk-bucket/pkg/actors/testkit"
+	"github.com/stretchr/testify/assert"
+)
+
+// forwarder forwards published messages to target, exiting upon "exit".
+type forwarder struct {
+	target actors.Pid
+}
+
+func (f *forwarder) OnMessage(r actors.Runtime, m any) {
+	switch msg := m.(type) {
+	case Published:
+		if msg.Message == "exit" {
+			r.Exit(nil)
+			return
+		}
+		r.Tell(f.target, msg)
+	}
+}
+
+func TestBroker(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	sys := testkit.NewSystem()
+	probe := testkit.NewTestProbe(t, sys)
+	broker := sys.Spawn(ctx, NewBroker())
+
+	probe.Tell(broker, Subscribe{Pattern: "orders/*", Subscriber: probe.Pid()})
+	probe.Tell(broker, Subscribe{Pattern: "orders/*", Subscriber: probe.Pid()})
+	probe.Tell(broker, Subscribe{Pattern: "payments/**", Subscriber: probe.Pid()})
+	probe.Tell(broker, Publish{Topic: "orders/created", Message: 1})
+	probe.Tell(broker, Publish{Topic: "payments/card/settled", Message: 2})
+	probe.Tell(broker, Publish{Topic: "inventory/changed", Message: 3})
+	probe.ExpectMsg(Published{Topic: "orders/created", Message: 1})
+	probe.ExpectMsg(Published{Topic: "payments/card/settled", Message: 2})
+	probe.ExpectNoMsg(time.Second)
+
+	probe.Tell(broker, Unsubscribe{Pattern: "orders/*", Subscriber: probe.Pid()})
+	probe.Tell(broker, Publish{Topic: "orders/created", Message: 4})
+	probe.Tell(broker, Publish{Topic: "payments/refunded", Message: 5})
+	probe.ExpectMsg(Published{Topic: "payments/refunded", Message: 5})
+	probe.ExpectNoMsg(time.Second)
+}
+
+func TestBrokerDropsExitedSubscribers(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	sys := testkit.NewSystem()
+	probe := testkit.NewTestProbe(t, sys)
+	broker := sys.Spawn(ctx, NewBroker())
+	first := sys.Spawn(ctx, &forwarder{target: probe.Pid()})
+	second := sys.Spawn(ctx, &forwarder{target: probe.Pid()})
+	probe.Tell(broker, Subscribe{Pattern: "events", Subscriber: first})
+	probe.Tell(broker, Subscribe{Pattern: "events", Subscriber: second})
+	probe.Tell(broker, Subscribe{Pattern: "control", Subscriber: first})
+
+	probe.Tell(broker, Publish{Topic: "events", Message: "in order"})
+	probe.ExpectMsg(Published{Topic: "events", Message: "in order"})
+	probe.ExpectMsg(Published{Topic: "events", Message: "in order"})
+
+	probe.Watch(first)
+	probe.Tell(broker, Publish{Topic: "control", Message: "exit"})
+	probe.ExpectTerminated(first)
+	sys.RunUntilIdle()
+
+	probe.Tell(broker, Publish{Topic: "events", Message: "after exit"})
+	probe.ExpectMsg(Published{Topic: "events", Message: "after exit"})
+	probe.ExpectNoMsg(time.Second)
+	assert.Empty(t, sys.DeadLetters(), "messages delivered to the exited subscriber")
+}
@@ -0,0 +1,30 @@
+package broker
+
+import "github.com/meschbach/go-junk-bucket/pkg/actors"
+
+// Subscribe delivers messages published to topics matching Pattern to Subscriber.  Subscribing to the same pattern
+// more than once has no further effect.
+type Subscribe struct {
+	Pattern    string
+	Subscriber actors.Pid
+}
+
+// Unsubscribe stops delivering messages published to topics matching Pattern to Subscriber.  The Pattern must be
+// the one given to Subscribe.
+type Unsubscribe struct {
+	Pattern    string
+	Subscriber actors.Pid
+}
+
+// Publish delivers Message to the subscribers of Topic as Published.
+type Publish struct {
+	Topic   string
+	Message any
+}
+
+// Published is delivered to each subscriber with a pattern matching the topic of a Publish.  A subscriber with several
+// matching patterns receives the message once per pattern.
+type Published struct {
+	Topic   string
+	Message any
+}
@@ -0,0 +1,30 @@
+package broker
+
+import "strings"
+
+// Topics are separated into segments by "/".  Within a pattern "*" matches exactly one segment while a final "**"
+// matches any number of remaining segments, including none.  For example "orders/*/created" matches
+// "orders/42/created" and "orders/**" matches both "orders" and "orders/42/created".
+const (
+	separator      = "/"
+	anySegment     = "*"
+	remainingTopic = "**"
+)
+
+// Matches reports whether topic matches pattern.
+func Matches(pattern, topic string) bool {
+	patternParts := strings.Split(pattern, separator)
+	topicParts := strings.Split(topic, separator)
+	for index, part := range patternParts {
+		if part == remainingTopic && index == len(patternParts)-1 {
+			return true
+		}
+		if index >= len(topicParts) {
+			return false
+		}
+		if part != anySegment && part != topicParts[index] {
+			return false
+		}
+	}
+	return len(patternParts) == len(topicParts)
+}
@@ -0,0 +1,30 @@
+package broker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatches(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		pattern string
+		topic   string
+		matches bool
+	}{
+		{"orders", "orders", true},
+		{"orders", "orders/42", false},
+		{"orders/*/created", "orders/42/created", true},
+		{"orders/*/created", "orders/42/deleted", false},
+		{"orders/*", "orders", false},
+		{"orders/**", "orders", true},
+		{"orders/**", "orders/42/created", true},
+		{"**", "anything/at/all", true},
+		{"orders/**/created", "orders/**/created", true},
+		{"orders/**/created", "orders/42/created", false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.matches, Matches(c.pattern, c.topic), "%q against %q", c.pattern, c.topic)
+	}
+}
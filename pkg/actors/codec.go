@@ -0,0 +1,191 @@
+package actors
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// MessageCodec converts values of registered message types to and from bytes.
+type MessageCodec interface {
+	//Name is the stable identifier of the codec, recorded with each encoded message
+	Name() string
+	Marshal(value any) ([]byte, error)
+	//Unmarshal decodes data into the value pointed to by into
+	Unmarshal(data []byte, into any) error
+}
+
+type jsonCodec struct{}
+
+func (j jsonCodec) Name() string {
+	return "json"
+}
+
+func (j jsonCodec) Marshal(value any) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (j jsonCodec) Unmarshal(data []byte, into any) error {
+	return json.Unmarshal(data, into)
+}
+
+type gobCodec struct{}
+
+func (g gobCodec) Name() string {
+	return "gob"
+}
+
+func (g gobCodec) Marshal(value any) ([]byte, error) {
+	var out bytes.Buffer
+	if err := gob.NewEncoder(&out).Encode(value); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func (g gobCodec) Unmarshal(data []byte, into any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(into)
+}
+
+// JSONCodec encodes messages with encoding/json.  Only exported fields are retained.
+var JSONCodec MessageCodec = jsonCodec{}
+
+// GobCodec encodes messages with encoding/gob.  Only exported fields are retained.
+var GobCodec MessageCodec = gobCodec{}
+
+// EncodedMessage is the portable form of a registered message, suitable for storage or transmission.  The zero value
+// represents a nil message.
+type EncodedMessage struct {
+	//Type is the registered name of the message type
+	Type string
+	//Codec is the name of the codec used to produce Data
+	Codec string
+	Data  []byte
+}
+
+// UnregisteredMessageError indicates a message type has not been registered with the CodecRegistry.
+type UnregisteredMessageError struct {
+	Type reflect.Type
+}
+
+func (u *UnregisteredMessageError) Error() string {
+	return fmt.Sprintf("message type %s is not registered with the codec registry", u.Type)
+}
+
+// UnknownMessageNameError indicates an encoded message names a type which has not been registered.
+type UnknownMessageNameError struct {
+	Name string
+}
+
+func (u *UnknownMessageNameError) Error() string {
+	return fmt.Sprintf("no message type registered as %q", u.Name)
+}
+
+// DuplicateRegistrationError indicates either the name or the type is already registered.
+type DuplicateRegistrationError struct {
+	Name string
+	Type reflect.Type
+}
+
+func (d *DuplicateRegistrationError) Error() string {
+	return fmt.Sprintf("message type %s or name %q is already registered", d.Type, d.Name)
+}
+
+type registeredMessage struct {
+	name  string
+	kind  reflect.Type
+	codec MessageCodec
+}
+
+// CodecRegistry maps message types to stable names and the codec used to encode them.  Safe for use by multiple
+// goroutines.
+type CodecRegistry struct {
+	lock   sync.RWMutex
+	byName map[string]registeredMessage
+	byType map[reflect.Type]registeredMessage
+}
+
+func NewCodecRegistry() *CodecRegistry {
+	return &CodecRegistry{
+		byName: make(map[string]registeredMessage),
+		byType: make(map[reflect.Type]registeredMessage),
+	}
+}
+
+// Register associates the type of sample with name, encoding values with codec.  Values are decoded as the same type
+// as sample, so pointer types are decoded as pointers.
+func (c *CodecRegistry) Register(name string, sample any, codec MessageCodec) error {
+	kind := reflect.TypeOf(sample)
+	if kind == nil {
+		return fmt.Errorf("unable to register nil sample as %q", name)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, hasName := c.byName[name]
+	_, hasType := c.byType[kind]
+	if hasName || hasType {
+		return &DuplicateRegistrationError{Name: name, Type: kind}
+	}
+	entry := registeredMessage{name: name, kind: kind, codec: codec}
+	c.byName[name] = entry
+	c.byType[kind] = entry
+	return nil
+}
+
+// RegisterMessage registers message type M with the registry under name.
+func RegisterMessage[M any](c *CodecRegistry, name string, codec MessageCodec) error {
+	var sample M
+	return c.Register(name, sample, codec)
+}
+
+func (c *CodecRegistry) lookupType(kind reflect.Type) (registeredMessage, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	entry, has := c.byType[kind]
+	if !has {
+		return entry, &UnregisteredMessageError{Type: kind}
+	}
+	return entry, nil
+}
+
+// Encode converts m into its portable form.
+func (c *CodecRegistry) Encode(m any) (EncodedMessage, error) {
+	if m == nil {
+		return EncodedMessage{}, nil
+	}
+	entry, err := c.lookupType(reflect.TypeOf(m))
+	if err != nil {
+		return EncodedMessage{}, err
+	}
+	data, err := entry.codec.Marshal(m)
+	if err != nil {
+		return EncodedMessage{}, fmt.Errorf("encoding %q: %w", entry.name, err)
+	}
+	return EncodedMessage{Type: entry.name, Codec: entry.codec.Name(), Data: data}, nil
+}
+
+// Decode converts the portable form back into a message of the registered type.
+func (c *CodecRegistry) Decode(encoded EncodedMessage) (any, error) {
+	if encoded.Type == "" {
+		return nil, nil
+	}
+	c.lock.RLock()
+	entry, has := c.byName[encoded.Type]
+	c.lock.RUnlock()
+	if !has {
+		return nil, &UnknownMessageNameError{Name: encoded.Type}
+	}
+	if encoded.Codec != entry.codec.Name() {
+		return nil, fmt.Errorf("message %q encoded with %q but registered with %q", encoded.Type, encoded.Codec, entry.codec.Name())
+	}
+
+	target := reflect.New(entry.kind)
+	if err := entry.codec.Unmarshal(encoded.Data, target.Interface()); err != nil {
+		return nil, fmt.Errorf("decoding %q: %w", encoded.Type, err)
+	}
+	return target.Elem().Interface(), nil
+}
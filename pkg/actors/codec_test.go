@@ -0,0 +1,74 @@
+package actors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type codecExample struct {
+	Who   Pid
+	Count int
+}
+
+func TestCodecRegistry(t *testing.T) {
+	t.Parallel()
+
+	for _, codec := range []MessageCodec{JSONCodec, GobCodec} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			t.Parallel()
+			registry := NewCodecRegistry()
+			require.NoError(t, RegisterMessage[codecExample](registry, "example", codec))
+			require.NoError(t, RegisterMessage[*codecExample](registry, "example-ref", codec))
+
+			t.Run("Values round trip as values", func(t *testing.T) {
+				t.Parallel()
+				encoded, err := registry.Encode(codecExample{Who: Pid{Node: 1, Process: 2}, Count: 3})
+				require.NoError(t, err)
+				assert.Equal(t, "example", encoded.Type)
+				assert.Equal(t, codec.Name(), encoded.Codec)
+
+				decoded, err := registry.Decode(encoded)
+				require.NoError(t, err)
+				assert.Equal(t, codecExample{Who: Pid{Node: 1, Process: 2}, Count: 3}, decoded)
+			})
+
+			t.Run("Pointers round trip as pointers", func(t *testing.T) {
+				t.Parallel()
+				encoded, err := registry.Encode(&codecExample{Count: 4})
+				require.NoError(t, err)
+				decoded, err := registry.Decode(encoded)
+				require.NoError(t, err)
+				assert.Equal(t, &codecExample{Count: 4}, decoded)
+			})
+		})
+	}
+
+	t.Run("Given an unregistered type", func(t *testing.T) {
+		t.Parallel()
+		registry := NewCodecRegistry()
+		_, err := registry.Encode("not registered")
+		var unregistered *UnregisteredMessageError
+		assert.ErrorAs(t, err, &unregistered)
+	})
+
+	t.Run("Given a duplicate registration", func(t *testing.T) {
+		t.Parallel()
+		registry := NewCodecRegistry()
+		require.NoError(t, RegisterMessage[codecExample](registry, "example", JSONCodec))
+		var duplicate *DuplicateRegistrationError
+		assert.ErrorAs(t, RegisterMessage[codecExample](registry, "other", JSONCodec), &duplicate)
+		assert.ErrorAs(t, RegisterMessage[Start](registry, "example", JSONCodec), &duplicate)
+	})
+
+	t.Run("nil round trips", func(t *testing.T) {
+		t.Parallel()
+		registry := NewCodecRegistry()
+		encoded, err := registry.Encode(nil)
+		require.NoError(t, err)
+		decoded, err := registry.Decode(encoded)
+		require.NoError(t, err)
+		assert.Nil(t, decoded)
+	})
+}
@@ -3,8 +3,10 @@ package counter
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/meschbach/go-junk-bucket/pkg/actors/local"
+	"github.com/meschbach/go-junk-bucket/pkg/actors/testkit"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -27,3 +29,21 @@ func TestSimpleCounter(t *testing.T) {
 		assert.Equal(t, uint(3), value)
 	}
 }
+
+func TestSimpleCounterDeterministic(t *testing.T) {
+	t.Parallel()
+	root := context.Background()
+	sys := testkit.NewSystem()
+	probe := testkit.NewTestProbe(t, sys)
+
+	pid := sys.Spawn(root, &counterActor{})
+	sys.Tell(root, pid, increment{})
+	sys.Tell(root, pid, increment{})
+	sys.Tell(root, pid, tell{who: probe.Pid()})
+	sys.Tell(root, pid, increment{})
+	sys.Tell(root, pid, tell{who: probe.Pid()})
+
+	probe.ExpectMsg(uint(2))
+	probe.ExpectMsg(uint(3))
+	probe.ExpectNoMsg(time.Second)
+}
@@ -5,7 +5,9 @@ import (
 	"testing"
 
 	"github.com/meschbach/go-junk-bucket/pkg/actors/local"
+	"github.com/meschbach/go-junk-bucket/pkg/actors/testkit"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPingPongActors(t *testing.T) {
@@ -31,3 +33,28 @@ func TestPingPongActors(t *testing.T) {
 		assert.Equal(t, "apingpong", v)
 	}
 }
+
+func TestPingPongActorsDeterministic(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	sys := testkit.NewSystem()
+	probe := testkit.NewTestProbe(t, sys)
+	ping := sys.Spawn(ctx, &appender{suffix: "ping"})
+	pong := sys.Spawn(ctx, &appender{suffix: "pong"})
+	director := sys.Spawn(ctx, &stringDirector{
+		apply:  pong,
+		inform: probe.Pid(),
+	})
+	sys.RunUntilIdle()
+
+	sys.Tell(ctx, ping, &appendString{
+		to:   "a",
+		next: director,
+	})
+	for range 3 {
+		require.True(t, sys.Step())
+	}
+	assert.Equal(t, 0, sys.Pending())
+	probe.ExpectMsg("apingpong")
+}
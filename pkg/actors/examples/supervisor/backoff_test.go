@@ -0,0 +1,32 @@
+package supervisor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors/local"
+	"github.com/meschbach/go-junk-bucket/pkg/actors/supervisor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestartBackoff(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	sys := local.NewSystem()
+	port := sys.NewPort()
+	spec := supervisor.Spec{Strategy: supervisor.OneForOne, Backoff: supervisor.Backoff{Initial: 10 * time.Millisecond, Max: time.Minute}}
+	sup := sys.Spawn(ctx, supervisor.FromBehavior(&strategySupervisor{ids: []string{"a"}, spec: spec}))
+	sys.Tell(ctx, sup, supervisor.WatchState{Observer: port.Pid()})
+
+	for _, expected := range []time.Duration{10 * time.Millisecond, 20 * time.Millisecond} {
+		failed := sys.Lookup(ctx, "/a")
+		sys.Tell(ctx, failed, giveUp{})
+
+		restarting, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, supervisor.StateRestarting{Ids: []string{"a"}, Delay: expected}, restarting)
+		awaitReady(t, ctx, port)
+		assert.NotEqual(t, failed, sys.Lookup(ctx, "/a"))
+	}
+}
@@ -0,0 +1,176 @@
+package supervisor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/meschbach/go-junk-bucket/pkg/actors/local"
+	"github.com/meschbach/go-junk-bucket/pkg/actors/supervisor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// request sends the message built with a ReplyTo targeting the port, returning the supervisor's reply.
+func request(t *testing.T, ctx context.Context, sys actors.System, port actors.Port, sup actors.Pid, build func(replyTo actors.ReplyTo) any) supervisor.ChildReply {
+	sys.Tell(ctx, sup, build(actors.ReplyTo{Pid: port.Pid(), Correlation: actors.NextCorrelation()}))
+	for {
+		msg, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		if reply, ok := msg.(actors.Reply); ok {
+			return reply.Value.(supervisor.ChildReply)
+		}
+	}
+}
+
+// exitWatcher monitors target on behalf of report.
+type exitWatcher struct {
+	target actors.Pid
+	report actors.Pid
+}
+
+func (e *exitWatcher) OnMessage(r actors.Runtime, m any) {
+	if _, ok := m.(*actors.Start); ok {
+		r.Monitor2(e.target, e.report)
+		r.Exit(nil)
+	}
+}
+
+// awaitExit waits for the child to exit.  The supervisor monitored the child first so has been notified of the exit
+// before the port.
+func awaitExit(t *testing.T, ctx context.Context, sys actors.System, child actors.Pid) {
+	port := sys.NewPort()
+	defer port.Close(ctx)
+	sys.Spawn(ctx, &exitWatcher{target: child, report: port.Pid()})
+	for {
+		msg, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		switch exit := msg.(type) {
+		case actors.NormalExit:
+			if exit.Who == child {
+				return
+			}
+		case actors.PanicExit:
+			if exit.Who == child {
+				return
+			}
+		}
+	}
+}
+
+func TestRestartTypes(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Transient children are not restarted after exiting normally", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := local.NewSystem()
+		port := sys.NewPort()
+		sup := sys.Spawn(ctx, supervisor.FromBehavior(&strategySupervisor{ids: []string{"a"}, restart: supervisor.Transient, spec: supervisor.Spec{Strategy: supervisor.OneForOne}}))
+		a := sys.Lookup(ctx, "/a")
+
+		sys.Tell(ctx, a, quit{})
+		awaitExit(t, ctx, sys, a)
+		reply := request(t, ctx, sys, port, sup, func(replyTo actors.ReplyTo) any {
+			return supervisor.TerminateChild{Id: "a", ReplyTo: replyTo}
+		})
+		var missing *supervisor.NoSuchChildError
+		assert.ErrorAs(t, reply.Err, &missing)
+	})
+
+	t.Run("Transient children are restarted after panicking", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := local.NewSystem()
+		port := sys.NewPort()
+		sup := sys.Spawn(ctx, supervisor.FromBehavior(&strategySupervisor{ids: []string{"a"}, restart: supervisor.Transient, spec: supervisor.Spec{Strategy: supervisor.OneForOne}}))
+		sys.Tell(ctx, sup, supervisor.WatchState{Observer: port.Pid()})
+
+		sys.Tell(ctx, sys.Lookup(ctx, "/a"), giveUp{})
+		awaitReady(t, ctx, port)
+		assert.Equal(t, uint(0), valueOf(t, ctx, sys, port, "/a"))
+	})
+
+	t.Run("Temporary children are never restarted", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := local.NewSystem()
+		port := sys.NewPort()
+		sup := sys.Spawn(ctx, supervisor.FromBehavior(&strategySupervisor{ids: []string{"a"}, restart: supervisor.Temporary, spec: supervisor.Spec{Strategy: supervisor.OneForOne}}))
+
+		a := sys.Lookup(ctx, "/a")
+		sys.Tell(ctx, a, giveUp{})
+		awaitExit(t, ctx, sys, a)
+		reply := request(t, ctx, sys, port, sup, func(replyTo actors.ReplyTo) any {
+			return supervisor.TerminateChild{Id: "a", ReplyTo: replyTo}
+		})
+		var missing *supervisor.NoSuchChildError
+		assert.ErrorAs(t, reply.Err, &missing)
+	})
+}
+
+func TestDynamicChildren(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Children may be added, terminated and deleted", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := local.NewSystem()
+		port := sys.NewPort()
+		sup := sys.Spawn(ctx, supervisor.FromBehavior(&strategySupervisor{spec: supervisor.Spec{Strategy: supervisor.OneForOne}}))
+
+		spec := supervisor.ChildSpec{Id: "dynamic", Start: func() actors.MessageActor { return &givesUpActor{} }}
+		started := request(t, ctx, sys, port, sup, func(replyTo actors.ReplyTo) any {
+			return supervisor.StartChildRequest{Spec: spec, ReplyTo: replyTo}
+		})
+		require.NoError(t, started.Err)
+		assert.Equal(t, started.Pid, sys.Lookup(ctx, "/dynamic"))
+
+		duplicate := request(t, ctx, sys, port, sup, func(replyTo actors.ReplyTo) any {
+			return supervisor.StartChildRequest{Spec: spec, ReplyTo: replyTo}
+		})
+		var exists *supervisor.ChildExistsError
+		assert.ErrorAs(t, duplicate.Err, &exists)
+
+		running := request(t, ctx, sys, port, sup, func(replyTo actors.ReplyTo) any {
+			return supervisor.DeleteChild{Id: "dynamic", ReplyTo: replyTo}
+		})
+		var isRunning *supervisor.ChildRunningError
+		assert.ErrorAs(t, running.Err, &isRunning)
+
+		terminated := request(t, ctx, sys, port, sup, func(replyTo actors.ReplyTo) any {
+			return supervisor.TerminateChild{Id: "dynamic", ReplyTo: replyTo}
+		})
+		require.NoError(t, terminated.Err)
+		deleted := request(t, ctx, sys, port, sup, func(replyTo actors.ReplyTo) any {
+			return supervisor.DeleteChild{Id: "dynamic", ReplyTo: replyTo}
+		})
+		require.NoError(t, deleted.Err)
+	})
+
+	t.Run("SimpleOneForOne spawns workers from the template", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := local.NewSystem()
+		port := sys.NewPort()
+		sup := sys.Spawn(ctx, supervisor.FromBehavior(&strategySupervisor{ids: []string{"worker"}, spec: supervisor.Spec{Strategy: supervisor.SimpleOneForOne}}))
+
+		first := request(t, ctx, sys, port, sup, func(replyTo actors.ReplyTo) any {
+			return supervisor.StartChildRequest{ReplyTo: replyTo}
+		})
+		second := request(t, ctx, sys, port, sup, func(replyTo actors.ReplyTo) any {
+			return supervisor.StartChildRequest{ReplyTo: replyTo}
+		})
+		require.NoError(t, first.Err)
+		require.NoError(t, second.Err)
+		assert.Equal(t, "worker-1", first.Id)
+		assert.Equal(t, "worker-2", second.Id)
+
+		sys.Tell(ctx, sup, supervisor.WatchState{Observer: port.Pid()})
+		sys.Tell(ctx, second.Pid, increment{})
+		sys.Tell(ctx, first.Pid, giveUp{})
+		awaitReady(t, ctx, port)
+		assert.NotEqual(t, first.Pid, sys.Lookup(ctx, "/worker-1"), "failed worker is restarted")
+		assert.Equal(t, uint(1), valueOf(t, ctx, sys, port, "/worker-2"))
+	})
+}
@@ -8,6 +8,7 @@ import (
 type increment struct{}
 type giveUp struct{}
 type tell struct{ who actors.Pid }
+type quit struct{}
 
 type givesUpActor struct {
 	value uint
@@ -24,6 +25,8 @@ func (g *givesUpActor) OnMessage(r actors.Runtime, m any) {
 		r.Tell(msg.who, g.value)
 	case giveUp:
 		panic("giving up")
+	case quit:
+		r.Exit(nil)
 	}
 }
 
@@ -0,0 +1,47 @@
+package supervisor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors/local"
+	"github.com/meschbach/go-junk-bucket/pkg/actors/supervisor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestRestartMetrics(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	t.Cleanup(func() {
+		require.NoError(t, provider.Shutdown(context.Background()))
+	})
+
+	sys := local.NewSystem()
+	port := sys.NewPort()
+	sup := sys.Spawn(ctx, supervisor.FromBehavior(&strategySupervisor{ids: []string{"a", "b"}, spec: supervisor.Spec{Strategy: supervisor.OneForOne, MeterProvider: provider}}))
+	sys.Tell(ctx, sup, supervisor.WatchState{Observer: port.Pid()})
+	require.Equal(t, uint(0), valueOf(t, ctx, sys, port, "/a"))
+
+	sys.Tell(ctx, sys.Lookup(ctx, "/a"), giveUp{})
+	awaitReady(t, ctx, port)
+
+	var collected metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &collected))
+	require.Len(t, collected.ScopeMetrics, 1)
+	require.Len(t, collected.ScopeMetrics[0].Metrics, 1)
+	restarts := collected.ScopeMetrics[0].Metrics[0]
+	assert.Equal(t, "actors.supervisor.restarts", restarts.Name)
+	points := restarts.Data.(metricdata.Sum[int64]).DataPoints
+	require.Len(t, points, 1)
+	assert.Equal(t, int64(1), points[0].Value)
+	path, _ := points[0].Attributes.Value(local.PathKey)
+	assert.Equal(t, "/a", path.AsString())
+	child, _ := points[0].Attributes.Value(attribute.Key("child"))
+	assert.Equal(t, "a", child.AsString())
+}
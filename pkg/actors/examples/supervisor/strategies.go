@@ -0,0 +1,24 @@
+package supervisor
+
+import (
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/meschbach/go-junk-bucket/pkg/actors/supervisor"
+)
+
+// strategySupervisor supervises a givesUpActor for each of the ids.
+type strategySupervisor struct {
+	ids     []string
+	restart supervisor.RestartType
+	spec    supervisor.Spec
+}
+
+func (s *strategySupervisor) Init(bif actors.Runtime) supervisor.Spec {
+	spec := s.spec
+	spec.Children = nil
+	for _, id := range s.ids {
+		spec.Children = append(spec.Children, supervisor.ChildSpec{Id: id, Restart: s.restart, Start: func() actors.MessageActor {
+			return &givesUpActor{}
+		}})
+	}
+	return spec
+}
@@ -0,0 +1,116 @@
+package supervisor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/meschbach/go-junk-bucket/pkg/actors/local"
+	"github.com/meschbach/go-junk-bucket/pkg/actors/supervisor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// valueOf queries the current value of the named child.
+func valueOf(t *testing.T, ctx context.Context, sys actors.System, port actors.Port, name string) uint {
+	sys.Tell(ctx, sys.Lookup(ctx, name), tell{who: port.Pid()})
+	value, err := port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	return value.(uint)
+}
+
+func awaitReady(t *testing.T, ctx context.Context, port actors.Port) {
+	for {
+		msg, err := port.ReceiveWith(ctx)
+		require.NoError(t, err, "waiting for restart")
+		if _, ok := msg.(supervisor.StateReady); ok {
+			return
+		}
+	}
+}
+
+func TestStrategies(t *testing.T) {
+	t.Parallel()
+
+	t.Run("OneForOne restarts only the failed child", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := local.NewSystem()
+		port := sys.NewPort()
+		sup := sys.Spawn(ctx, supervisor.FromBehavior(&strategySupervisor{ids: []string{"a", "b"}, spec: supervisor.Spec{Strategy: supervisor.OneForOne}}))
+		sys.Tell(ctx, sup, supervisor.WatchState{Observer: port.Pid()})
+
+		sys.Tell(ctx, sys.Lookup(ctx, "/a"), increment{})
+		sys.Tell(ctx, sys.Lookup(ctx, "/b"), increment{})
+		require.Equal(t, uint(1), valueOf(t, ctx, sys, port, "/a"))
+		require.Equal(t, uint(1), valueOf(t, ctx, sys, port, "/b"))
+
+		sys.Tell(ctx, sys.Lookup(ctx, "/a"), giveUp{})
+		awaitReady(t, ctx, port)
+		assert.Equal(t, uint(0), valueOf(t, ctx, sys, port, "/a"))
+		assert.Equal(t, uint(1), valueOf(t, ctx, sys, port, "/b"))
+	})
+
+	t.Run("RestForOne restarts the failed child and those after it", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := local.NewSystem()
+		port := sys.NewPort()
+		sup := sys.Spawn(ctx, supervisor.FromBehavior(&strategySupervisor{ids: []string{"a", "b", "c"}, spec: supervisor.Spec{Strategy: supervisor.RestForOne}}))
+		sys.Tell(ctx, sup, supervisor.WatchState{Observer: port.Pid()})
+
+		for _, name := range []string{"/a", "/b", "/c"} {
+			sys.Tell(ctx, sys.Lookup(ctx, name), increment{})
+			require.Equal(t, uint(1), valueOf(t, ctx, sys, port, name))
+		}
+
+		sys.Tell(ctx, sys.Lookup(ctx, "/b"), giveUp{})
+		awaitReady(t, ctx, port)
+		assert.Equal(t, uint(1), valueOf(t, ctx, sys, port, "/a"))
+		assert.Equal(t, uint(0), valueOf(t, ctx, sys, port, "/b"))
+		assert.Equal(t, uint(0), valueOf(t, ctx, sys, port, "/c"))
+	})
+
+	t.Run("OneForAll restarts every child", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := local.NewSystem()
+		port := sys.NewPort()
+		sup := sys.Spawn(ctx, supervisor.FromBehavior(&strategySupervisor{ids: []string{"a", "b"}}))
+		sys.Tell(ctx, sup, supervisor.WatchState{Observer: port.Pid()})
+
+		sys.Tell(ctx, sys.Lookup(ctx, "/b"), increment{})
+		require.Equal(t, uint(1), valueOf(t, ctx, sys, port, "/b"))
+
+		sys.Tell(ctx, sys.Lookup(ctx, "/a"), giveUp{})
+		awaitReady(t, ctx, port)
+		assert.Equal(t, uint(0), valueOf(t, ctx, sys, port, "/b"))
+	})
+}
+
+func TestRestartIntensity(t *testing.T) {
+	t.Parallel()
+	for name, spec := range map[string]supervisor.Spec{
+		"within the period":         {Strategy: supervisor.OneForOne, MaxRestarts: 1, Period: time.Minute},
+		"within the default period": {Strategy: supervisor.OneForOne, MaxRestarts: 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := t.Context()
+			sys := local.NewSystem()
+			port := sys.NewPort()
+			exits := sys.NewPort()
+			sup := sys.Spawn(ctx, supervisor.FromBehavior(&strategySupervisor{ids: []string{"a"}, spec: spec}), actors.MonitorOpt{Tell: exits.Pid()})
+			sys.Tell(ctx, sup, supervisor.WatchState{Observer: port.Pid()})
+
+			sys.Tell(ctx, sys.Lookup(ctx, "/a"), giveUp{})
+			awaitReady(t, ctx, port)
+			sys.Tell(ctx, sys.Lookup(ctx, "/a"), giveUp{})
+
+			exit, err := exits.ReceiveWith(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, actors.NewPanicExit(sup, nil), exit, "supervisor escalates by exiting abnormally")
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package typed
+
+import (
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+)
+
+type counterMessage interface {
+	counterMessage()
+}
+
+type increment struct{}
+
+func (increment) counterMessage() {}
+
+type tell struct {
+	who actors.Pid
+}
+
+func (tell) counterMessage() {}
+
+type counterActor struct {
+	count uint
+}
+
+func (c *counterActor) OnMessage(r actors.Runtime, m counterMessage) {
+	switch msg := m.(type) {
+	case increment:
+		c.count++
+	case tell:
+		r.Tell(msg.who, c.count)
+	}
+}
+
+// watcher spawns a typed counter, monitors it, and reports the exit.
+type watcher struct {
+	report actors.Pid
+}
+
+func (w *watcher) OnMessage(r actors.Runtime, m actors.NormalExit) {
+	r.Tell(w.report, m.Momento)
+}
+
+func (w *watcher) OnSignal(r actors.Runtime, m any) {
+	switch m.(type) {
+	case *actors.Start:
+		counter := actors.SpawnTyped[counterMessage](r, &exitingCounter{}, actors.MonitorOpt{Tell: r.Self(), Momento: "counter"})
+		counter.Tell(r, increment{})
+	}
+}
+
+type exitingCounter struct{}
+
+func (e *exitingCounter) OnMessage(r actors.Runtime, m counterMessage) {
+	r.Exit(nil)
+}
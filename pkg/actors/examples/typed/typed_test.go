@@ -0,0 +1,45 @@
+package typed
+
+import (
+	"context"
+	"testing"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/meschbach/go-junk-bucket/pkg/actors/local"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypedCounter(t *testing.T) {
+	t.Parallel()
+	root, done := context.WithCancel(context.Background())
+	t.Cleanup(done)
+
+	sys := local.NewSystem()
+
+	port := sys.NewPort()
+	counter := actors.SpawnTypedOn[counterMessage](root, sys, &counterActor{})
+	counter.TellFrom(root, sys, increment{})
+	counter.TellFrom(root, sys, increment{})
+	counter.TellFrom(root, sys, tell{who: port.Pid()})
+	value, err := port.ReceiveWith(root)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, uint(2), value)
+	}
+}
+
+func TestTypedMonitoring(t *testing.T) {
+	t.Parallel()
+	root, done := context.WithCancel(context.Background())
+	t.Cleanup(done)
+
+	sys := local.NewSystem()
+
+	port := sys.NewPort()
+	actors.SpawnTypedOn[actors.NormalExit](root, sys, &watcher{report: port.Pid()})
+	value, err := port.ReceiveWith(root)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "counter", value)
+	}
+}
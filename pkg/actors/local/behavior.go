@@ -0,0 +1,57 @@
+package local
+
+import (
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+)
+
+const defaultStashCapacity = 128
+
+// handler resolves the current handler for user messages.
+func (r *runtime) handler() actors.MessageActor {
+	if count := len(r.behaviors); count > 0 {
+		return r.behaviors[count-1]
+	}
+	return r.consumer
+}
+
+func (c *container) Become(handler actors.MessageActor) {
+	if count := len(c.r.behaviors); count > 0 {
+		c.r.behaviors[count-1] = handler
+		return
+	}
+	c.r.behaviors = append(c.r.behaviors, handler)
+}
+
+func (c *container) BecomeStacked(handler actors.MessageActor) {
+	c.r.behaviors = append(c.r.behaviors, handler)
+}
+
+func (c *container) Unbecome() {
+	if count := len(c.r.behaviors); count > 0 {
+		c.r.behaviors[count-1] = nil
+		c.r.behaviors = c.r.behaviors[:count-1]
+	}
+}
+
+func (c *container) Stash(m any) error {
+	c.r.changes.Lock()
+	defer c.r.changes.Unlock()
+	if len(c.r.stash) >= c.r.stashCapacity {
+		return &actors.StashFullError{Capacity: c.r.stashCapacity}
+	}
+	//stashed messages retain the sender of the message being processed
+	entry := traceDecorator(c.tickContext, &userMessage{m: m})
+	entry.sender = originFrom(c.tickContext)
+	c.r.stash = append(c.r.stash, mailboxEntry{message: entry, bounded: true})
+	return nil
+}
+
+func (c *container) UnstashAll() {
+	c.r.changes.Lock()
+	defer c.r.changes.Unlock()
+	if len(c.r.stash) == 0 {
+		return
+	}
+	c.r.mailbox.requeue(c.r.stash)
+	c.r.stash = nil
+}
@@ -0,0 +1,154 @@
+package local
+
+import (
+	"testing"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type becomeReady struct{}
+
+type becomeReset struct{}
+
+// initializing stashes all requests until ready, then replays them against the ready behavior.
+type initializing struct {
+	report actors.Pid
+}
+
+func (i *initializing) OnMessage(r actors.Runtime, m any) {
+	switch m.(type) {
+	case *actors.Start:
+	case becomeReady:
+		r.Become(&ready{report: i.report})
+		r.UnstashAll()
+	case string:
+		if err := r.Stash(m); err != nil {
+			r.Tell(i.report, err)
+		}
+	}
+}
+
+type ready struct {
+	report actors.Pid
+}
+
+func (d *ready) OnMessage(r actors.Runtime, m any) {
+	switch m.(type) {
+	case becomeReset:
+		r.Unbecome()
+	case string:
+		r.Tell(d.report, m)
+	}
+}
+
+type becomeToggle struct{}
+
+type becomeStacked struct{}
+
+// toggle answers "which?" with its name, switching between on and off with each becomeToggle.
+type toggle struct {
+	name   string
+	report actors.Pid
+}
+
+func (s *toggle) OnMessage(r actors.Runtime, m any) {
+	switch m.(type) {
+	case becomeToggle:
+		if s.name == "on" {
+			r.Become(&toggle{name: "off", report: s.report})
+		} else {
+			r.Become(&toggle{name: "on", report: s.report})
+		}
+	case becomeStacked:
+		r.BecomeStacked(&toggle{name: "stacked", report: s.report})
+	case becomeReset:
+		r.Unbecome()
+	case string:
+		r.Tell(s.report, s.name)
+	}
+}
+
+func TestBecome(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Stashed messages are replayed in order against the new behavior", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		port := sys.NewPort()
+		pid := sys.Spawn(ctx, &initializing{report: port.Pid()})
+
+		sys.Tell(ctx, pid, "first")
+		sys.Tell(ctx, pid, "second")
+		sys.Tell(ctx, pid, becomeReady{})
+		sys.Tell(ctx, pid, "third")
+
+		for _, expected := range []string{"first", "second", "third"} {
+			msg, err := port.ReceiveWith(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, expected, msg)
+		}
+
+		sys.Tell(ctx, pid, becomeReset{})
+		sys.Tell(ctx, pid, "stashed again")
+		sys.Tell(ctx, pid, becomeReady{})
+		msg, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "stashed again", msg)
+	})
+
+	t.Run("Become replaces the current behavior", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		port := sys.NewPort()
+		pid := sys.Spawn(ctx, &toggle{name: "actor", report: port.Pid()})
+		for range 10 {
+			sys.Tell(ctx, pid, becomeToggle{})
+		}
+		sys.Tell(ctx, pid, "which?")
+		msg, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "off", msg)
+		assert.Len(t, sys.(*system).pid2target(pid).(*runtime).behaviors, 1, "replaced behaviors are discarded")
+
+		sys.Tell(ctx, pid, becomeReset{})
+		sys.Tell(ctx, pid, "which?")
+		msg, err = port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "actor", msg)
+	})
+
+	t.Run("BecomeStacked retains the current behavior", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		port := sys.NewPort()
+		pid := sys.Spawn(ctx, &toggle{name: "actor", report: port.Pid()})
+		sys.Tell(ctx, pid, becomeToggle{})
+		sys.Tell(ctx, pid, becomeStacked{})
+		for _, expected := range []string{"stacked", "on", "actor"} {
+			sys.Tell(ctx, pid, "which?")
+			msg, err := port.ReceiveWith(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, expected, msg)
+			sys.Tell(ctx, pid, becomeReset{})
+		}
+	})
+
+	t.Run("Stash is bounded", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		port := sys.NewPort()
+		pid := sys.Spawn(ctx, &initializing{report: port.Pid()}, actors.StashOpt{Capacity: 1})
+
+		sys.Tell(ctx, pid, "first")
+		sys.Tell(ctx, pid, "second")
+		msg, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, &actors.StashFullError{Capacity: 1}, msg)
+	})
+}
@@ -2,6 +2,8 @@ package local
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 
 	"github.com/meschbach/go-junk-bucket/pkg/actors"
 )
@@ -22,20 +24,18 @@ func (c *CompositeLogger) Warn(format string, args ...any) {
 	}
 }
 
+// Fatal records the problem with each logger then panics with the formatted message.
 func (c *CompositeLogger) Fatal(format string, args ...any) {
-	lastIndex := len(c.Loggers)
 	dispatch := func(l actors.Logger) {
 		defer func() {
 			recover()
 		}()
 		l.Fatal(format, args...)
 	}
-	for _, l := range c.Loggers[0 : lastIndex-1] {
-		go dispatch(l)
+	for _, l := range c.Loggers {
+		dispatch(l)
 	}
-
-	l := c.Loggers[lastIndex]
-	l.Fatal(format, args...)
+	panic(fmt.Sprintf(format, args...))
 }
 
 func (c *CompositeLogger) Error(format string, args ...any) {
@@ -44,14 +44,65 @@ func (c *CompositeLogger) Error(format string, args ...any) {
 	}
 }
 
+func (c *CompositeLogger) Enabled(level slog.Level) bool {
+	for _, l := range c.Loggers {
+		if actors.Structured(l).Enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CompositeLogger) Log(level slog.Level, msg string, args ...any) {
+	for _, l := range c.Loggers {
+		actors.Structured(l).Log(level, msg, args...)
+	}
+}
+
+func (c *CompositeLogger) With(args ...any) actors.StructuredLogger {
+	loggers := make([]actors.StructuredLogger, len(c.Loggers))
+	for index, l := range c.Loggers {
+		loggers[index] = actors.Structured(l).With(args...)
+	}
+	return &compositeStructuredLogger{loggers: loggers}
+}
+
+// compositeStructuredLogger is a CompositeLogger after attributes have been added via With.
+type compositeStructuredLogger struct {
+	loggers []actors.StructuredLogger
+}
+
+func (c *compositeStructuredLogger) Enabled(level slog.Level) bool {
+	for _, l := range c.loggers {
+		if l.Enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *compositeStructuredLogger) Log(level slog.Level, msg string, args ...any) {
+	for _, l := range c.loggers {
+		l.Log(level, msg, args...)
+	}
+}
+
+func (c *compositeStructuredLogger) With(args ...any) actors.StructuredLogger {
+	loggers := make([]actors.StructuredLogger, len(c.loggers))
+	for index, l := range c.loggers {
+		loggers[index] = l.With(args...)
+	}
+	return &compositeStructuredLogger{loggers: loggers}
+}
+
 type CompositeLoggingStrategy struct {
 	Loggers []LoggingStrategy
 }
 
-func (c *CompositeLoggingStrategy) buildLogger(ctx context.Context, who actors.Pid) actors.Logger {
+func (c *CompositeLoggingStrategy) buildLogger(ctx context.Context, who actors.Pid, path func() string) actors.Logger {
 	var loggers []actors.Logger
 	for _, strategy := range c.Loggers {
-		loggers = append(loggers, strategy.buildLogger(ctx, who))
+		loggers = append(loggers, strategy.buildLogger(ctx, who, path))
 	}
 	return &CompositeLogger{Loggers: loggers}
 }
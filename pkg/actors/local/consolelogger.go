@@ -2,26 +2,32 @@ package local
 
 import (
 	"fmt"
+	"log/slog"
 
 	"github.com/meschbach/go-junk-bucket/pkg/actors"
 )
 
 type consoleLogger struct {
-	who actors.Pid
+	who   actors.Pid
+	level slog.Leveler
+	attrs []any
 }
 
-func (c *consoleLogger) write(level string, format string, args []any) {
-	newArgs := append([]any{c.who.String(), level}, args...)
+func (c *consoleLogger) write(level slog.Level, format string, args []any) {
+	if !c.Enabled(level) {
+		return
+	}
+	newArgs := append([]any{c.who.String(), levelName(level)}, args...)
 	newFormat := "%s %s: " + format + "\n"
 	fmt.Printf(newFormat, newArgs...)
 }
 
 func (c *consoleLogger) Info(format string, args ...any) {
-	c.write("info", format, args)
+	c.write(slog.LevelInfo, format, args)
 }
 
 func (c *consoleLogger) Warn(fmt string, args ...any) {
-	c.write("warn", fmt, args)
+	c.write(slog.LevelWarn, fmt, args)
 }
 
 func (c *consoleLogger) Fatal(format string, args ...any) {
@@ -30,5 +36,40 @@ func (c *consoleLogger) Fatal(format string, args ...any) {
 }
 
 func (c *consoleLogger) Error(format string, args ...any) {
-	c.write("error", format, args)
+	c.write(slog.LevelError, format, args)
+}
+
+func (c *consoleLogger) Enabled(level slog.Level) bool {
+	minimum := slog.LevelInfo
+	if c.level != nil {
+		minimum = c.level.Level()
+	}
+	return level >= minimum
+}
+
+func (c *consoleLogger) Log(level slog.Level, msg string, args ...any) {
+	if !c.Enabled(level) {
+		return
+	}
+	c.write(level, "%s", []any{actors.FormatStructured(msg, append(append([]any{}, c.attrs...), args...)...)})
+}
+
+func (c *consoleLogger) With(args ...any) actors.StructuredLogger {
+	return &consoleLogger{who: c.who, level: c.level, attrs: append(append([]any{}, c.attrs...), args...)}
+}
+
+// levelName is the lowercase name of level, as written by the console and OpenTelemetry loggers.
+func levelName(level slog.Level) string {
+	switch level {
+	case slog.LevelDebug:
+		return "debug"
+	case slog.LevelInfo:
+		return "info"
+	case slog.LevelWarn:
+		return "warn"
+	case slog.LevelError:
+		return "error"
+	default:
+		return level.String()
+	}
 }
@@ -40,7 +40,7 @@ func (c *container) SpawnMonitor(actor actors.MessageActor) actors.Pid {
 }
 
 func (c *container) Log() actors.Logger {
-	return c.r.system.loggingStrategy.buildLogger(c.tickContext, c.r.self)
+	return c.r.system.loggingStrategy.buildLogger(c.tickContext, c.r.self, c.r.namedPath)
 }
 
 func (c *container) SpawnMailbox() actors.Port {
@@ -48,6 +48,10 @@ func (c *container) SpawnMailbox() actors.Port {
 }
 
 func (c *container) Monitor2(watched actors.Pid, watcher actors.Pid) {
+	if !c.r.system.isRemote(watched) && c.r.system.pid2target(watched) == nil {
+		c.r.system.Tell(c.tickContext, watcher, actors.NormalExit{Who: watched, ExitValue: actors.NoProcess{}})
+		return
+	}
 	c.r.system.execute(c.tickContext, watched, &startMonitoring{listener: watcher})
 }
 
@@ -63,16 +67,49 @@ func (c *container) Exit(result any) {
 	c.r.submit(c.tickContext, actorExitSignal{result: result})
 }
 
+func (c *container) MailboxDepth(who actors.Pid) int {
+	switch target := c.r.system.pid2target(who).(type) {
+	case *runtime:
+		return target.mailbox.depth()
+	case *port:
+		return len(target.mailbox)
+	default:
+		return -1
+	}
+}
+
 func (c *container) Register(name string, who actors.Pid) {
+	c.r.changes.Lock()
 	c.r.names[name] = who
+	c.r.changes.Unlock()
+	c.r.system.naming.Add(1)
 }
 
 func (c *container) Context() context.Context {
 	return c.tickContext
 }
 
+func (c *container) Defer(fn func(r actors.Runtime)) {
+	c.r.submit(c.tickContext, &deferredTick{fn: fn})
+}
+
+type deferredTick struct {
+	fn func(r actors.Runtime)
+}
+
+func (d *deferredTick) execute(ctx context.Context, r *runtime) {
+	d.fn(&container{tickContext: ctx, r: r})
+}
+
+func (d *deferredTick) name() string {
+	return "deferred"
+}
+
 func (c *container) Unregister(name string) {
+	c.r.changes.Lock()
 	delete(c.r.names, name)
+	c.r.changes.Unlock()
+	c.r.system.naming.Add(1)
 }
 
 func (c *container) LookupPath(path string) actors.Pid {
@@ -93,7 +130,9 @@ func (c *container) LookupPath(path string) actors.Pid {
 	parts := strings.Split(path, "/")
 	for index, part := range parts {
 		c.r.system.execute(c.tickContext, component, &lookupNamedComponent{component: part, tell: resolver})
+		resume := blocking(c.tickContext)
 		result := mailbox.Receive()
+		resume()
 		switch msg := result.(type) {
 		case foundName:
 			component = msg.who
@@ -0,0 +1,115 @@
+package local
+
+import (
+	"context"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/meschbach/go-junk-bucket/pkg/emitter"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey uint8
+
+const (
+	senderKey contextKey = iota
+	//originKey is the sender of the message currently being processed by an actor
+	originKey
+	//quietKey suppresses dead letters, preventing a dead letter watcher from producing further dead letters
+	quietKey
+	//detachedKey marks deliveries which must never block their goroutine, such as those from node links and timers
+	detachedKey
+	//tickKey carries the tickState of ticks run by a pooled scheduler
+	tickKey
+)
+
+func withSender(ctx context.Context, sender actors.Pid) context.Context {
+	return context.WithValue(ctx, senderKey, sender)
+}
+
+func senderFrom(ctx context.Context) actors.Pid {
+	if sender, ok := ctx.Value(senderKey).(actors.Pid); ok {
+		return sender
+	}
+	return actors.Pid{}
+}
+
+// withDetached marks deliveries made with the context as unable to block.  Messages to full mailboxes are dead lettered
+// and system signals ignore the capacity of the system lane.
+func withDetached(ctx context.Context) context.Context {
+	return context.WithValue(ctx, detachedKey, true)
+}
+
+func isDetached(ctx context.Context) bool {
+	detached, _ := ctx.Value(detachedKey).(bool)
+	return detached
+}
+
+// senderBlocker provides the blocker for senders within ctx, nil if the sender is detached and may not wait.
+func senderBlocker(ctx context.Context) blocker {
+	if isDetached(ctx) {
+		return nil
+	}
+	return func() func() {
+		return blocking(ctx)
+	}
+}
+
+// withinTick reports whether ctx belongs to an actor handling a message, as opposed to ports, timers, node links or
+// goroutines outside of the system.
+func withinTick(ctx context.Context) bool {
+	_, ok := ctx.Value(originKey).(actors.Pid)
+	return ok && !isDetached(ctx)
+}
+
+func originFrom(ctx context.Context) actors.Pid {
+	if origin, ok := ctx.Value(originKey).(actors.Pid); ok {
+		return origin
+	}
+	return actors.Pid{}
+}
+
+// deadLetter reports the letter, recording path as the named path of the target.  Targets which are not actors of
+// this system, or which no longer exist, have an empty path.
+func (s *system) deadLetter(ctx context.Context, path string, letter actors.DeadLetter) {
+	if quiet, _ := ctx.Value(quietKey).(bool); quiet {
+		return
+	}
+	span := trace.SpanFromContext(ctx)
+	span.AddEvent("dead-letter", trace.WithAttributes(attribute.Stringer("target", letter.Target), attribute.Stringer("reason", letter.Reason)))
+	s.metrics.deadLetters.Add(ctx, 1, metric.WithAttributes(PathKey.String(path), attribute.Stringer("reason", letter.Reason)))
+	if err := s.deadLetters.Emit(context.WithValue(ctx, quietKey, true), letter); err != nil {
+		span.RecordError(err)
+	}
+}
+
+// deadLetterEntry reports a queued user message or timer delivery which will not be consumed.
+func (r *runtime) deadLetterEntry(ctx context.Context, message tracedDecorator, reason actors.DeadLetterReason) {
+	switch next := message.next.(type) {
+	case *userMessage:
+		r.system.deadLetter(ctx, r.namedPath(), actors.DeadLetter{Target: r.self, Sender: message.sender, Message: next.m, Reason: reason})
+	case *timerFired:
+		r.system.deadLetter(ctx, r.namedPath(), actors.DeadLetter{Target: r.self, Sender: message.sender, Message: next.timer.message, Reason: reason})
+	}
+}
+
+// DeadLetters provides a subscription to every message the system is unable to deliver.
+func DeadLetters(sys actors.System) (emitter.Emitter[actors.DeadLetter], error) {
+	s, err := asLocal(sys)
+	if err != nil {
+		return nil, err
+	}
+	return s.deadLetters, nil
+}
+
+// ForwardDeadLetters tells watcher each message the system is unable to deliver until the subscription is turned off.
+func ForwardDeadLetters(sys actors.System, watcher actors.Pid) (*emitter.Subscription[actors.DeadLetter], error) {
+	s, err := asLocal(sys)
+	if err != nil {
+		return nil, err
+	}
+	return s.deadLetters.On(func(ctx context.Context, letter actors.DeadLetter) {
+		s.Tell(ctx, watcher, letter)
+	}), nil
+}
@@ -0,0 +1,184 @@
+package local
+
+import (
+	"context"
+	"testing"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stashingExiter stashes numbers, exiting without unstashing them on any string.
+type stashingExiter struct{}
+
+func (s *stashingExiter) OnMessage(r actors.Runtime, m any) {
+	switch m.(type) {
+	case int:
+		if err := r.Stash(m); err != nil {
+			panic(err)
+		}
+	case string:
+		r.Exit("done")
+	}
+}
+
+// teller tells the target the message upon starting.
+type teller struct {
+	target  actors.Pid
+	message any
+}
+
+func (t *teller) OnMessage(r actors.Runtime, m any) {
+	if _, ok := m.(*actors.Start); ok {
+		r.Tell(t.target, t.message)
+	}
+}
+
+func TestDeadLetters(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Messages to missing targets are reported with the sender", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		watcher := sys.NewPort()
+		_, err := ForwardDeadLetters(sys, watcher.Pid())
+		require.NoError(t, err)
+
+		sender := sys.NewPort()
+		missing := actors.Pid{Process: 9999}
+		sender.Tell(ctx, missing, "hello")
+		letter, err := watcher.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, actors.DeadLetter{Target: missing, Sender: sender.Pid(), Message: "hello", Reason: actors.DeadLetterNoTarget}, letter)
+	})
+
+	t.Run("Messages to closed ports are reported", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		watcher := sys.NewPort()
+		_, err := ForwardDeadLetters(sys, watcher.Pid())
+		require.NoError(t, err)
+
+		closed := sys.NewPort()
+		closed.Close(ctx)
+		closed.(*port).told(ctx, "late")
+		letter, err := watcher.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, actors.DeadLetter{Target: closed.Pid(), Message: "late", Reason: actors.DeadLetterPortClosed}, letter)
+	})
+
+	t.Run("Senders blocked on a full port are released when it closes", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		watcher := sys.NewPort()
+		_, err := ForwardDeadLetters(sys, watcher.Pid())
+		require.NoError(t, err)
+
+		full := sys.NewPort().(*port)
+		for i := 0; i < cap(full.mailbox); i++ {
+			full.told(ctx, i)
+		}
+		told := make(chan struct{})
+		go func() {
+			full.told(ctx, "blocked")
+			close(told)
+		}()
+		full.Close(ctx)
+		<-told
+		letter, err := watcher.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, actors.DeadLetter{Target: full.Pid(), Message: "blocked", Reason: actors.DeadLetterPortClosed}, letter)
+	})
+
+	t.Run("Messages dropped by overflow are reported", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		letters := make(chan actors.DeadLetter, 4)
+		events, err := DeadLetters(sys)
+		require.NoError(t, err)
+		events.On(func(ctx context.Context, letter actors.DeadLetter) {
+			letters <- letter
+		})
+
+		actor := &gatedActor{gate: make(chan struct{}), release: make(chan struct{})}
+		defer close(actor.release)
+		gate := actor.gate
+		target := sys.Spawn(ctx, actor, actors.MailboxOpt{Capacity: 1, Overflow: actors.OverflowDropNewest})
+		sys.Tell(ctx, target, 0)
+		<-gate
+
+		sys.Tell(ctx, target, 1)
+		sys.Tell(ctx, target, 2)
+		letter := <-letters
+		assert.Equal(t, target, letter.Target)
+		assert.Equal(t, 2, letter.Message)
+		assert.Equal(t, actors.DeadLetterMailboxOverflow, letter.Reason)
+	})
+
+	t.Run("Full mailboxes reject messages from outside the system", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		watcher := sys.NewPort()
+		_, err := ForwardDeadLetters(sys, watcher.Pid())
+		require.NoError(t, err)
+
+		actor := &gatedActor{gate: make(chan struct{}), release: make(chan struct{})}
+		defer close(actor.release)
+		gate := actor.gate
+		target := sys.Spawn(ctx, actor, actors.MailboxOpt{Capacity: 1, Overflow: actors.OverflowFail})
+		sys.Tell(ctx, target, 0)
+		<-gate
+
+		sys.Tell(ctx, target, 1)
+		assert.NotPanics(t, func() {
+			sys.Tell(ctx, target, 2)
+		})
+		letter, err := watcher.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, actors.DeadLetter{Target: target, Message: 2, Reason: actors.DeadLetterMailboxFull}, letter)
+	})
+
+	t.Run("Actors sending to full mailboxes fail", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		watcher := sys.NewPort()
+
+		actor := &gatedActor{gate: make(chan struct{}), release: make(chan struct{})}
+		defer close(actor.release)
+		gate := actor.gate
+		target := sys.Spawn(ctx, actor, actors.MailboxOpt{Capacity: 1, Overflow: actors.OverflowFail})
+		sys.Tell(ctx, target, 0)
+		<-gate
+		sys.Tell(ctx, target, 1)
+
+		sender := sys.Spawn(ctx, &teller{target: target, message: 2}, actors.MonitorOpt{Tell: watcher.Pid()})
+		exit, err := watcher.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, actors.PanicExit{Who: sender}, exit)
+	})
+
+	t.Run("Messages queued when an actor exits are reported", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		letters := make(chan actors.DeadLetter, 4)
+		events, err := DeadLetters(sys)
+		require.NoError(t, err)
+		events.On(func(ctx context.Context, letter actors.DeadLetter) {
+			letters <- letter
+		})
+
+		target := sys.Spawn(ctx, &stashingExiter{})
+		sys.Tell(ctx, target, 7)
+		sys.Tell(ctx, target, "exit")
+		letter := <-letters
+		assert.Equal(t, actors.DeadLetter{Target: target, Message: 7, Reason: actors.DeadLetterTargetDone}, letter)
+	})
+}
@@ -0,0 +1,198 @@
+package local
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/meschbach/go-junk-bucket/pkg/stdhttp"
+)
+
+// inspectTimeout bounds how long InspectHandler waits for busy actors to report.
+const inspectTimeout = time.Second
+
+// ActorSnapshot describes an actor at the time of inspection.
+type ActorSnapshot struct {
+	Pid    actors.Pid  `json:"pid"`
+	Parent *actors.Pid `json:"parent,omitempty"`
+	//Path is the absolute name of the actor as resolved by System.Lookup
+	Path string `json:"path"`
+	//Names are the actors registered within this actor
+	Names           map[string]actors.Pid `json:"names,omitempty"`
+	MailboxDepth    int                   `json:"mailboxDepth"`
+	MailboxCapacity int                   `json:"mailboxCapacity"`
+	Monitors        int                   `json:"monitors"`
+	Links           int                   `json:"links"`
+	Timers          int                   `json:"timers"`
+	Stashed         int                   `json:"stashed"`
+	TrapExits       bool                  `json:"trapExits"`
+	//Responsive is false when the actor did not respond before the inspection deadline, in which case only the mailbox
+	//and relationships are reported
+	Responsive bool            `json:"responsive"`
+	Children   []ActorSnapshot `json:"children,omitempty"`
+}
+
+// SystemSnapshot describes the actors living within a system at the time of inspection.
+type SystemSnapshot struct {
+	Node  uint64 `json:"node"`
+	Ports int    `json:"ports"`
+	//Actors are the actors without a living parent, each containing their descendants
+	Actors []ActorSnapshot `json:"actors"`
+}
+
+// actorDetails is the state of the actor only safely accessed within a tick.
+type actorDetails struct {
+	names     map[string]actors.Pid
+	monitors  int
+	links     int
+	timers    int
+	stashed   int
+	trapExits bool
+}
+
+type inspectSignal struct {
+	reply chan actorDetails
+}
+
+func (i *inspectSignal) execute(ctx context.Context, r *runtime) {
+	//timers are forgotten by Cancel from any goroutine, so are read under the lock with the stash and names
+	r.changes.Lock()
+	stashed := len(r.stash)
+	timers := len(r.timers)
+	names := make(map[string]actors.Pid, len(r.names))
+	for name, pid := range r.names {
+		names[name] = pid
+	}
+	r.changes.Unlock()
+	i.reply <- actorDetails{
+		names:     names,
+		monitors:  len(r.monitoring),
+		links:     len(r.links),
+		timers:    timers,
+		stashed:   stashed,
+		trapExits: r.trapExits,
+	}
+}
+
+func (i *inspectSignal) name() string {
+	return "inspect"
+}
+
+// Inspect captures a snapshot of the actor tree.  Each actor reports its own state between messages; actors which
+// have not done so once ctx is done are reported as unresponsive.
+func Inspect(ctx context.Context, sys actors.System) (*SystemSnapshot, error) {
+	s, err := asLocal(sys)
+	if err != nil {
+		return nil, err
+	}
+
+	//inspection is detached so a full system lane never blocks the caller beyond ctx
+	runtimes, ports := s.inventory()
+	replies := make([]chan actorDetails, len(runtimes))
+	depths := make([]int, len(runtimes))
+	for index, r := range runtimes {
+		depths[index] = r.mailbox.depth()
+		replies[index] = make(chan actorDetails, 1)
+		if ctx.Err() == nil {
+			r.signal(withDetached(ctx), &inspectSignal{reply: replies[index]})
+		}
+	}
+
+	snapshots := make(map[*runtime]*ActorSnapshot, len(runtimes))
+	for index, r := range runtimes {
+		snapshot := &ActorSnapshot{Pid: r.self, MailboxDepth: depths[index], MailboxCapacity: r.mailbox.capacity}
+		select {
+		case details := <-replies[index]:
+			snapshot.Responsive = true
+			snapshot.Names = details.names
+			snapshot.Monitors = details.monitors
+			snapshot.Links = details.links
+			snapshot.Timers = details.timers
+			snapshot.Stashed = details.stashed
+			snapshot.TrapExits = details.trapExits
+		case <-r.finished:
+		case <-ctx.Done():
+		}
+		snapshots[r] = snapshot
+	}
+
+	out := &SystemSnapshot{Node: s.node, Ports: ports}
+	for _, r := range runtimes {
+		if _, has := snapshots[r.parent]; !has {
+			out.Actors = append(out.Actors, assembleSnapshot(r, "/", snapshots))
+		}
+	}
+	sortSnapshots(out.Actors)
+	return out, nil
+}
+
+// assembleSnapshot nests the snapshots of the children within the snapshot of r.
+func assembleSnapshot(r *runtime, path string, snapshots map[*runtime]*ActorSnapshot) ActorSnapshot {
+	snapshot := snapshots[r]
+	snapshot.Path = path
+	if r.parent != nil {
+		parent := r.parent.self
+		snapshot.Parent = &parent
+	}
+	for _, child := range r.childRuntimes() {
+		if _, has := snapshots[child]; !has {
+			continue
+		}
+		name := "<anonymous>"
+		for registered, pid := range snapshot.Names {
+			if pid == child.self {
+				name = registered
+			}
+		}
+		childPath := path + name
+		if path != "/" {
+			childPath = path + "/" + name
+		}
+		snapshot.Children = append(snapshot.Children, assembleSnapshot(child, childPath, snapshots))
+	}
+	sortSnapshots(snapshot.Children)
+	return *snapshot
+}
+
+func sortSnapshots(snapshots []ActorSnapshot) {
+	slices.SortFunc(snapshots, func(a, b ActorSnapshot) int {
+		return cmp.Compare(a.Pid.Process, b.Pid.Process)
+	})
+}
+
+// inventory lists the living actors along with the number of open ports.
+func (s *system) inventory() ([]*runtime, int) {
+	s.actorLock.RLock()
+	defer s.actorLock.RUnlock()
+	runtimes := make([]*runtime, 0, len(s.actors))
+	ports := 0
+	for _, target := range s.actors {
+		switch t := target.(type) {
+		case *runtime:
+			runtimes = append(runtimes, t)
+		case *port:
+			ports++
+		}
+	}
+	return runtimes, ports
+}
+
+// InspectHandler serves the snapshot produced by Inspect as JSON.  Intended for debugging live systems.
+func InspectHandler(sys actors.System) (http.Handler, error) {
+	if _, err := asLocal(sys); err != nil {
+		return nil, err
+	}
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ctx, done := context.WithTimeout(request.Context(), inspectTimeout)
+		defer done()
+		snapshot, err := Inspect(ctx, sys)
+		if err != nil {
+			stdhttp.InternalError(writer, request, err)
+			return
+		}
+		stdhttp.Ok(writer, request, snapshot)
+	}), nil
+}
@@ -0,0 +1,123 @@
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// namingParent registers a child under the given name, reporting once ready.
+type namingParent struct {
+	name   string
+	report actors.Pid
+}
+
+func (n *namingParent) OnMessage(r actors.Runtime, m any) {
+	if _, ok := m.(*actors.Start); ok {
+		child := r.Spawn(&echoActor{})
+		r.Register(n.name, child)
+		r.Monitor2(child, n.report)
+		r.Tell(n.report, child)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	sys := NewSystem()
+	port := sys.NewPort()
+	parent := sys.Spawn(ctx, &namingParent{name: "child", report: port.Pid()})
+	child, err := port.ReceiveWith(ctx)
+	require.NoError(t, err)
+
+	snapshot, err := Inspect(ctx, sys)
+	require.NoError(t, err)
+	assert.Equal(t, 1, snapshot.Ports)
+	require.Len(t, snapshot.Actors, 1)
+	root := snapshot.Actors[0]
+	assert.Equal(t, parent, root.Pid)
+	assert.Equal(t, "/", root.Path)
+	assert.True(t, root.Responsive)
+	assert.Equal(t, map[string]actors.Pid{"child": child.(actors.Pid)}, root.Names)
+
+	require.Len(t, root.Children, 1)
+	leaf := root.Children[0]
+	assert.Equal(t, child, leaf.Pid)
+	assert.Equal(t, &parent, leaf.Parent)
+	assert.Equal(t, "/child", leaf.Path)
+	assert.Equal(t, 1, leaf.Monitors)
+	assert.Equal(t, child, sys.Lookup(ctx, leaf.Path), "paths resolve via Lookup")
+}
+
+func TestInspectBusyActors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("A full system lane does not block inspection", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		actor := &gatedActor{gate: make(chan struct{}), release: make(chan struct{})}
+		defer close(actor.release)
+		gate := actor.gate
+		pid := sys.Spawn(ctx, actor, actors.MailboxOpt{SystemCapacity: 1})
+		sys.Tell(ctx, pid, 0)
+		<-gate
+		sys.(*system).pid2target(pid).(*runtime).signal(ctx, &inspectSignal{reply: make(chan actorDetails, 1)})
+
+		inspecting, done := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer done()
+		snapshot, err := Inspect(inspecting, sys)
+		require.NoError(t, err)
+		require.Len(t, snapshot.Actors, 1)
+		assert.False(t, snapshot.Actors[0].Responsive)
+	})
+
+	t.Run("Timers may be cancelled during inspection", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		port := sys.NewPort()
+		actor := &scheduling{report: port.Pid(), interval: true}
+		sys.Spawn(ctx, actor)
+		_, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+
+		cancelled := make(chan struct{})
+		go func() {
+			actor.timer.Cancel()
+			close(cancelled)
+		}()
+		snapshot, err := Inspect(ctx, sys)
+		require.NoError(t, err)
+		require.Len(t, snapshot.Actors, 1)
+		<-cancelled
+	})
+}
+
+func TestInspectHandler(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	sys := NewSystem()
+	port := sys.NewPort()
+	sys.Spawn(ctx, &namingParent{name: "child", report: port.Pid()})
+	_, err := port.ReceiveWith(ctx)
+	require.NoError(t, err)
+
+	handler, err := InspectHandler(sys)
+	require.NoError(t, err)
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequestWithContext(ctx, http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	var snapshot SystemSnapshot
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &snapshot))
+	require.Len(t, snapshot.Actors, 1)
+	assert.Equal(t, "/child", snapshot.Actors[0].Children[0].Path)
+}
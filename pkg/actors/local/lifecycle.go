@@ -1,12 +1,20 @@
 package local
 
-import "context"
+import (
+	"context"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+)
 
 type terminateSignal struct {
 }
 
 func (t *terminateSignal) execute(ctx context.Context, r *runtime) {
 	r.done()
+	for _, l := range r.monitoring {
+		r.system.Tell(ctx, l.listener, actors.NormalExit{Who: r.self, ExitValue: actors.Terminated{}, Momento: l.what})
+	}
+	r.notifyLinks(ctx, actors.Terminated{}, true)
 }
 
 func (t *terminateSignal) name() string {
@@ -0,0 +1,102 @@
+package local
+
+import (
+	"context"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type linkSignal struct {
+	with actors.Pid
+}
+
+func (l *linkSignal) execute(ctx context.Context, r *runtime) {
+	r.links[l.with] = struct{}{}
+}
+
+func (l *linkSignal) name() string {
+	return "link"
+}
+
+type unlinkSignal struct {
+	with actors.Pid
+}
+
+func (u *unlinkSignal) execute(ctx context.Context, r *runtime) {
+	delete(r.links, u.with)
+}
+
+func (u *unlinkSignal) name() string {
+	return "unlink"
+}
+
+// linkExitSignal notifies an actor a linked actor has exited.
+type linkExitSignal struct {
+	from     actors.Pid
+	reason   any
+	abnormal bool
+}
+
+func (l *linkExitSignal) execute(ctx context.Context, r *runtime) {
+	if _, linked := r.links[l.from]; !linked {
+		return
+	}
+	delete(r.links, l.from)
+
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Stringer("from", l.from), attribute.Bool("abnormal", l.abnormal), attribute.Bool("trapping", r.trapExits))
+	switch {
+	case r.trapExits:
+		//queued behind user messages so the exit never overtakes messages the linked actor sent before exiting
+		r.submit(ctx, &userMessage{m: actors.LinkExit{Who: l.from, Reason: l.reason, Abnormal: l.abnormal}})
+	case l.abnormal:
+		r.exitAbnormally(ctx, l.reason)
+	}
+}
+
+func (l *linkExitSignal) name() string {
+	return "link-exit"
+}
+
+// notifyLinks informs all linked actors of the exit of this actor.
+func (r *runtime) notifyLinks(ctx context.Context, reason any, abnormal bool) {
+	for linked := range r.links {
+		r.system.execute(ctx, linked, &linkExitSignal{from: r.self, reason: reason, abnormal: abnormal})
+	}
+	r.links = make(map[actors.Pid]struct{})
+}
+
+// exitAbnormally notifies monitors and links of the failure then stops the actor.
+func (r *runtime) exitAbnormally(ctx context.Context, reason any) {
+	for _, l := range r.monitoring {
+		r.system.Tell(ctx, l.listener, actors.NewPanicExit(r.self, l.what))
+	}
+	r.notifyLinks(ctx, reason, true)
+	r.done()
+}
+
+func (c *container) SpawnLink(actor actors.MessageActor, opts ...any) actors.Pid {
+	child := c.Spawn(actor, append(opts, actors.LinkOpt{With: c.r.self})...)
+	c.r.links[child] = struct{}{}
+	return child
+}
+
+func (c *container) Link(other actors.Pid) {
+	c.r.links[other] = struct{}{}
+	if !c.r.system.isRemote(other) && c.r.system.pid2target(other) == nil {
+		c.r.signal(c.tickContext, &linkExitSignal{from: other, reason: actors.NoProcess{}, abnormal: true})
+		return
+	}
+	c.r.system.execute(c.tickContext, other, &linkSignal{with: c.r.self})
+}
+
+func (c *container) Unlink(other actors.Pid) {
+	delete(c.r.links, other)
+	c.r.system.execute(c.tickContext, other, &unlinkSignal{with: c.r.self})
+}
+
+func (c *container) TrapExits(trap bool) {
+	c.r.trapExits = trap
+}
@@ -0,0 +1,307 @@
+package local
+
+import (
+	"testing"
+	"time"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type linkingParent struct {
+	child  actors.MessageActor
+	report actors.Pid
+	trap   bool
+}
+
+func (l *linkingParent) OnMessage(r actors.Runtime, m any) {
+	switch m.(type) {
+	case *actors.Start:
+		r.TrapExits(l.trap)
+		r.Tell(l.report, r.SpawnLink(l.child))
+	case string:
+		r.Tell(l.report, "pong")
+	default:
+		r.Tell(l.report, m)
+	}
+}
+
+// replyThenFail asks the child to tell the actor then panic.
+type replyThenFail struct {
+	to actors.Pid
+}
+
+type exitingChild struct{}
+
+func (e *exitingChild) OnMessage(r actors.Runtime, m any) {
+	switch msg := m.(type) {
+	case remoteGiveUp:
+		panic("giving up")
+	case replyThenFail:
+		r.Tell(msg.to, "reply")
+		panic("giving up")
+	case string:
+		r.Exit("done")
+	}
+}
+
+// blockingParent traps the exits of a linked child, blocking while handling a channel until it is closed.
+type blockingParent struct {
+	report actors.Pid
+}
+
+func (b *blockingParent) OnMessage(r actors.Runtime, m any) {
+	switch msg := m.(type) {
+	case *actors.Start:
+		r.TrapExits(true)
+		r.Tell(b.report, r.SpawnLink(&exitingChild{}))
+	case chan struct{}:
+		r.Tell(b.report, "blocked")
+		<-msg
+	default:
+		r.Tell(b.report, m)
+	}
+}
+
+func TestLinks(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Abnormal exits terminate linked actors", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		port := sys.NewPort()
+		parent := sys.Spawn(ctx, &linkingParent{child: &exitingChild{}, report: port.Pid()}, actors.MonitorOpt{Tell: port.Pid()})
+		child, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+
+		sys.Tell(ctx, child.(actors.Pid), remoteGiveUp{})
+		exit, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, actors.NewPanicExit(parent, nil), exit)
+	})
+
+	t.Run("Trapping actors receive abnormal exits as messages", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		port := sys.NewPort()
+		sys.Spawn(ctx, &linkingParent{child: &exitingChild{}, report: port.Pid(), trap: true})
+		child, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+
+		sys.Tell(ctx, child.(actors.Pid), remoteGiveUp{})
+		exit, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		if assert.IsType(t, actors.LinkExit{}, exit) {
+			linkExit := exit.(actors.LinkExit)
+			assert.Equal(t, child, linkExit.Who)
+			assert.True(t, linkExit.Abnormal)
+			assert.Equal(t, "giving up", linkExit.Reason)
+		}
+	})
+
+	t.Run("Normal exits do not terminate linked actors", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		port := sys.NewPort()
+		parent := sys.Spawn(ctx, &linkingParent{child: &exitingChild{}, report: port.Pid()})
+		child, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+
+		sys.Tell(ctx, child.(actors.Pid), "exit")
+		sys.Tell(ctx, parent, "ping")
+		reply, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "pong", reply)
+	})
+
+	t.Run("Terminating an actor terminates linked actors", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		port := sys.NewPort()
+		sys.Spawn(ctx, &linkingParent{child: &exitingChild{}, report: port.Pid(), trap: true})
+		child, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+
+		sys.Spawn(ctx, &terminator{target: child.(actors.Pid)})
+		exit, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, actors.LinkExit{Who: child.(actors.Pid), Reason: actors.Terminated{}, Abnormal: true}, exit)
+	})
+
+	t.Run("Trapped exits follow the messages sent before the exit", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		port := sys.NewPort()
+		parent := sys.Spawn(ctx, &blockingParent{report: port.Pid()})
+		child, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+
+		release := make(chan struct{})
+		sys.Tell(ctx, parent, release)
+		blocked, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "blocked", blocked)
+		sys.Tell(ctx, child.(actors.Pid), replyThenFail{to: parent})
+		parentMailbox := sys.(*system).pid2target(parent).(*runtime).mailbox
+		require.Eventually(t, func() bool {
+			return parentMailbox.depth() == 2
+		}, time.Second, time.Millisecond, "reply and exit are both waiting")
+		close(release)
+
+		reply, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "reply", reply)
+		exit, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, actors.LinkExit{Who: child.(actors.Pid), Reason: "giving up", Abnormal: true}, exit)
+	})
+
+	t.Run("Linking to an exited actor delivers NoProcess", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		port := sys.NewPort()
+		target := sys.Spawn(ctx, &exitingChild{}, actors.MonitorOpt{Tell: port.Pid()})
+		sys.Tell(ctx, target, "exit")
+		_, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+
+		sys.Spawn(ctx, &remoteLinker{target: target, report: port.Pid()})
+		ready, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "linked", ready)
+		exit, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, actors.LinkExit{Who: target, Reason: actors.NoProcess{}, Abnormal: true}, exit)
+	})
+
+	t.Run("Spawning linked to an exited actor delivers NoProcess", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		port := sys.NewPort()
+		target := sys.Spawn(ctx, &exitingChild{}, actors.MonitorOpt{Tell: port.Pid()})
+		sys.Tell(ctx, target, "exit")
+		_, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+
+		linked := sys.Spawn(ctx, &exitingChild{}, actors.LinkOpt{With: target}, actors.MonitorOpt{Tell: port.Pid()})
+		exit, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, actors.NewPanicExit(linked, nil), exit)
+	})
+
+	t.Run("Links reaching an exited runtime deliver NoProcess", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		port := sys.NewPort()
+		target := sys.Spawn(ctx, &exitingChild{}, actors.MonitorOpt{Tell: port.Pid()})
+		exited := sys.(*system).pid2target(target).(*runtime)
+		sys.Tell(ctx, target, "exit")
+		_, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+
+		linker := sys.Spawn(ctx, &pendingLinker{report: port.Pid()})
+		sys.Tell(ctx, linker, target)
+		ready, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "pending", ready)
+		exited.signal(ctx, &linkSignal{with: linker})
+		exit, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, actors.LinkExit{Who: target, Reason: actors.NoProcess{}, Abnormal: true}, exit)
+	})
+}
+
+// pendingLinker traps exits and records a link to each Pid it is told, as if its link signal were still in flight.
+type pendingLinker struct {
+	report actors.Pid
+}
+
+func (p *pendingLinker) OnMessage(r actors.Runtime, m any) {
+	switch msg := m.(type) {
+	case *actors.Start:
+		r.TrapExits(true)
+	case actors.Pid:
+		r.(*container).r.links[msg] = struct{}{}
+		r.Tell(p.report, "pending")
+	default:
+		r.Tell(p.report, m)
+	}
+}
+
+type terminator struct {
+	target actors.Pid
+}
+
+func (t *terminator) OnMessage(r actors.Runtime, m any) {
+	if _, ok := m.(*actors.Start); ok {
+		r.Terminate(t.target)
+	}
+}
+
+type remoteLinker struct {
+	target actors.Pid
+	report actors.Pid
+}
+
+func (l *remoteLinker) OnMessage(r actors.Runtime, m any) {
+	switch m.(type) {
+	case *actors.Start:
+		r.TrapExits(true)
+		r.Link(l.target)
+		r.Tell(l.report, "linked")
+	default:
+		r.Tell(l.report, m)
+	}
+}
+
+func TestRemoteLinks(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	first, second := linkedSystems(t, ctx)
+
+	target := first.Spawn(ctx, &echoActor{})
+	port := second.NewPort()
+	second.Spawn(ctx, &remoteLinker{target: target, report: port.Pid()})
+	ready, err := port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "linked", ready)
+
+	port.Tell(ctx, target, remoteGiveUp{})
+	exit, err := port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	if assert.IsType(t, actors.LinkExit{}, exit) {
+		assert.Equal(t, target, exit.(actors.LinkExit).Who)
+		assert.True(t, exit.(actors.LinkExit).Abnormal)
+	}
+}
+
+func TestRemoteLinkNoProcess(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	first, second := linkedSystems(t, ctx)
+
+	watcher := first.NewPort()
+	target := first.Spawn(ctx, &exitingChild{}, actors.MonitorOpt{Tell: watcher.Pid()})
+	first.Tell(ctx, target, "exit")
+	_, err := watcher.ReceiveWith(ctx)
+	require.NoError(t, err)
+
+	port := second.NewPort()
+	second.Spawn(ctx, &remoteLinker{target: target, report: port.Pid()})
+	ready, err := port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "linked", ready)
+
+	exit, err := port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, actors.LinkExit{Who: target, Reason: actors.NoProcess{}, Abnormal: true}, exit)
+}
@@ -2,18 +2,26 @@ package local
 
 import (
 	"context"
+	"log/slog"
 
 	"github.com/meschbach/go-junk-bucket/pkg/actors"
 )
 
+// LoggingStrategy builds the loggers given to actors and ports.  Loggers built by each strategy also implement
+// actors.StructuredLogger.
 type LoggingStrategy interface {
-	buildLogger(ctx context.Context, who actors.Pid) actors.Logger
+	//buildLogger creates a logger for who.  path resolves the named path of who and is nil for ports.
+	buildLogger(ctx context.Context, who actors.Pid, path func() string) actors.Logger
 }
 
-type ConsoleLoggingStrategy struct{}
+// ConsoleLoggingStrategy writes to standard out.
+type ConsoleLoggingStrategy struct {
+	//Level is the minimum level written, defaulting to slog.LevelInfo
+	Level slog.Leveler
+}
 
-func (c *ConsoleLoggingStrategy) buildLogger(ctx context.Context, who actors.Pid) actors.Logger {
-	return &consoleLogger{who: who}
+func (c *ConsoleLoggingStrategy) buildLogger(ctx context.Context, who actors.Pid, path func() string) actors.Logger {
+	return &consoleLogger{who: who, level: c.Level}
 }
 
 func (c *ConsoleLoggingStrategy) customizeSystem(s *system) {
@@ -0,0 +1,302 @@
+package local
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+)
+
+const defaultMailboxCapacity = 16
+
+// defaultSystemCapacity is the number of system signals which may be queued before senders block.
+const defaultSystemCapacity = 64
+
+type enqueueOutcome uint8
+
+const (
+	enqueued enqueueOutcome = iota
+	//enqueueDropped indicates a message was discarded to satisfy the capacity
+	enqueueDropped
+	//enqueueRejected indicates the message was refused as the mailbox is full
+	enqueueRejected
+	//enqueueClosed indicates the mailbox no longer accepts messages
+	enqueueClosed
+	//enqueueFull indicates the mailbox is full and the sender may not wait for room
+	enqueueFull
+)
+
+type mailboxEntry struct {
+	message tracedDecorator
+	//bounded entries count against the capacity and may be dropped
+	bounded bool
+}
+
+// mailbox is the queue of messages waiting for an actor.  Only bounded entries count towards the capacity, allowing
+// signals from the runtime to be delivered regardless of how backed up the actor is.  System signals, such as
+// monitoring and termination, wait within a separate lane which is always drained before the queue.
+type mailbox struct {
+	lock     sync.Mutex
+	arrived  sync.Cond
+	drained  sync.Cond
+	queue    []mailboxEntry
+	bounded  int
+	capacity int
+	policy   actors.OverflowPolicy
+	closed   bool
+	//system is the priority lane of system signals
+	system         []tracedDecorator
+	systemCapacity int
+	systemDrained  sync.Cond
+	//notify schedules the actor upon the arrival of messages when run by a pooled scheduler
+	notify func()
+	//scheduled is set while the actor is waiting for or running on a worker of a pooled scheduler
+	scheduled bool
+}
+
+func newMailbox(opt actors.MailboxOpt) *mailbox {
+	capacity := opt.Capacity
+	if capacity <= 0 {
+		capacity = defaultMailboxCapacity
+	}
+	systemCapacity := opt.SystemCapacity
+	if systemCapacity <= 0 {
+		systemCapacity = defaultSystemCapacity
+	}
+	m := &mailbox{
+		capacity:       capacity,
+		policy:         opt.Overflow,
+		systemCapacity: systemCapacity,
+	}
+	m.arrived.L = &m.lock
+	m.drained.L = &m.lock
+	m.systemDrained.L = &m.lock
+	return m
+}
+
+// blocker is invoked before a sender waits for room within a mailbox, returning the function to invoke once the sender
+// resumes.  A nil blocker indicates the sender may not wait.
+type blocker func() func()
+
+// push enqueues the message according to the overflow policy.  Senders which may not wait are refused instead of
+// blocking.  Any messages discarded to make room, or the message itself when it is not enqueued, are returned.
+func (m *mailbox) push(message tracedDecorator, bounded bool, block blocker) (enqueueOutcome, []tracedDecorator) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	outcome := enqueued
+	var dropped []tracedDecorator
+	if bounded && m.policy != actors.OverflowUnbounded {
+		var resume func()
+		for !m.closed && m.bounded >= m.capacity {
+			switch m.policy {
+			case actors.OverflowDropNewest:
+				return enqueueDropped, []tracedDecorator{message}
+			case actors.OverflowDropOldest:
+				dropped = append(dropped, m.dropOldest())
+				outcome = enqueueDropped
+			case actors.OverflowFail:
+				return enqueueRejected, []tracedDecorator{message}
+			default:
+				if block == nil {
+					return enqueueFull, []tracedDecorator{message}
+				}
+				if resume == nil {
+					resume = block()
+				}
+				m.drained.Wait()
+			}
+		}
+		if resume != nil {
+			resume()
+		}
+	}
+	if m.closed {
+		return enqueueClosed, append(dropped, message)
+	}
+	m.queue = append(m.queue, mailboxEntry{message: message, bounded: bounded})
+	if bounded {
+		m.bounded++
+	}
+	m.wake()
+	return outcome, dropped
+}
+
+// pushSystem enqueues the signal within the system lane, blocking while the lane is full.  Senders which may not wait
+// bypass the capacity of the lane.  The signal is returned if the mailbox has been closed.
+func (m *mailbox) pushSystem(signal tracedDecorator, block blocker) (enqueueOutcome, []tracedDecorator) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if block != nil && !m.closed && len(m.system) >= m.systemCapacity {
+		resume := block()
+		for !m.closed && len(m.system) >= m.systemCapacity {
+			m.systemDrained.Wait()
+		}
+		resume()
+	}
+	if m.closed {
+		return enqueueClosed, []tracedDecorator{signal}
+	}
+	m.system = append(m.system, signal)
+	m.wake()
+	return enqueued, nil
+}
+
+func (m *mailbox) dropOldest() tracedDecorator {
+	for index, entry := range m.queue {
+		if entry.bounded {
+			m.queue = append(m.queue[:index], m.queue[index+1:]...)
+			m.bounded--
+			return entry.message
+		}
+	}
+	return tracedDecorator{}
+}
+
+// requeue places entries at the front of the queue regardless of capacity.
+func (m *mailbox) requeue(entries []mailboxEntry) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.closed {
+		return
+	}
+	queue := make([]mailboxEntry, 0, len(entries)+len(m.queue))
+	queue = append(queue, entries...)
+	m.queue = append(queue, m.queue...)
+	for _, entry := range entries {
+		if entry.bounded {
+			m.bounded++
+		}
+	}
+	m.wake()
+}
+
+// wake informs the consumer a message has arrived, scheduling the actor if it is run by a pooled scheduler and not
+// already scheduled.  Must be called with the lock held.
+func (m *mailbox) wake() {
+	m.arrived.Signal()
+	if m.notify != nil && !m.scheduled {
+		m.scheduled = true
+		m.notify()
+	}
+}
+
+// attach switches the mailbox to notify a pooled scheduler upon the arrival of messages, scheduling the actor
+// immediately if messages are already waiting.
+func (m *mailbox) attach(notify func()) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.notify = notify
+	if len(m.queue) > 0 || len(m.system) > 0 {
+		m.wake()
+	}
+}
+
+// pop blocks until a message is available, returning false once the mailbox has been closed.  System signals are
+// returned before any queued message.
+func (m *mailbox) pop() (tracedDecorator, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for len(m.queue) == 0 && len(m.system) == 0 && !m.closed {
+		m.arrived.Wait()
+	}
+	return m.take()
+}
+
+// poll returns the next message without blocking, returning false if none are waiting or the mailbox has been closed.
+func (m *mailbox) poll() (tracedDecorator, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.take()
+}
+
+// yield releases the actor from its worker, returning true if messages remain and the actor must be rescheduled.
+func (m *mailbox) yield() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if !m.closed && (len(m.queue) > 0 || len(m.system) > 0) {
+		return true
+	}
+	m.scheduled = false
+	return false
+}
+
+// take removes the next message, preferring the system lane.  Must be called with the lock held.
+func (m *mailbox) take() (tracedDecorator, bool) {
+	if m.closed || (len(m.queue) == 0 && len(m.system) == 0) {
+		return tracedDecorator{}, false
+	}
+	if len(m.system) > 0 {
+		signal := m.system[0]
+		m.system[0] = tracedDecorator{}
+		m.system = m.system[1:]
+		m.systemDrained.Signal()
+		return signal, true
+	}
+	entry := m.queue[0]
+	m.queue[0] = mailboxEntry{}
+	m.queue = m.queue[1:]
+	if entry.bounded {
+		m.bounded--
+		m.drained.Signal()
+	}
+	return entry.message, true
+}
+
+// depth is the number of messages and signals waiting to be consumed.
+func (m *mailbox) depth() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.queue) + len(m.system)
+}
+
+// close discards all queued messages and signals, releasing any blocked senders or receivers.  The discarded signals
+// are returned ahead of the discarded messages.
+func (m *mailbox) close() []mailboxEntry {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	discarded := make([]mailboxEntry, 0, len(m.system)+len(m.queue))
+	for _, signal := range m.system {
+		discarded = append(discarded, mailboxEntry{message: signal})
+	}
+	discarded = append(discarded, m.queue...)
+	m.closed = true
+	m.queue = nil
+	m.system = nil
+	m.bounded = 0
+	m.arrived.Broadcast()
+	m.drained.Broadcast()
+	m.systemDrained.Broadcast()
+	return discarded
+}
+
+// dropCounters tracks the number of messages discarded or rejected under each overflow policy.
+type dropCounters struct {
+	counts [actors.OverflowUnbounded + 1]atomic.Uint64
+}
+
+func (d *dropCounters) record(policy actors.OverflowPolicy) {
+	if int(policy) < len(d.counts) {
+		d.counts[policy].Add(1)
+	}
+}
+
+func (d *dropCounters) snapshot() map[actors.OverflowPolicy]uint64 {
+	out := make(map[actors.OverflowPolicy]uint64, len(d.counts))
+	for policy := range d.counts {
+		out[actors.OverflowPolicy(policy)] = d.counts[policy].Load()
+	}
+	return out
+}
+
+// DroppedMessages reports the number of messages each overflow policy has discarded or rejected within the system.
+func DroppedMessages(sys actors.System) (map[actors.OverflowPolicy]uint64, error) {
+	s, err := asLocal(sys)
+	if err != nil {
+		return nil, err
+	}
+	return s.drops.snapshot(), nil
+}
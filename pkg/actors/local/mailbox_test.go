@@ -0,0 +1,247 @@
+package local
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mailboxMessage(ctx context.Context, value int) tracedDecorator {
+	return traceDecorator(ctx, &userMessage{m: value})
+}
+
+func outcomeOf(outcome enqueueOutcome, _ []tracedDecorator) enqueueOutcome {
+	return outcome
+}
+
+// mayWait allows the sender to wait for room without compensating a scheduler.
+func mayWait() func() {
+	return func() {}
+}
+
+func drainMailbox(m *mailbox) []any {
+	var out []any
+	m.lock.Lock()
+	for _, entry := range m.queue {
+		out = append(out, entry.message.next.(*userMessage).m)
+	}
+	m.lock.Unlock()
+	return out
+}
+
+func TestMailboxOverflow(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+
+	t.Run("DropNewest discards arriving messages", func(t *testing.T) {
+		t.Parallel()
+		m := newMailbox(actors.MailboxOpt{Capacity: 2, Overflow: actors.OverflowDropNewest})
+		assert.Equal(t, enqueued, outcomeOf(m.push(mailboxMessage(ctx, 1), true, mayWait)))
+		assert.Equal(t, enqueued, outcomeOf(m.push(mailboxMessage(ctx, 2), true, mayWait)))
+		assert.Equal(t, enqueueDropped, outcomeOf(m.push(mailboxMessage(ctx, 3), true, mayWait)))
+		assert.Equal(t, []any{1, 2}, drainMailbox(m))
+	})
+
+	t.Run("DropOldest discards queued messages", func(t *testing.T) {
+		t.Parallel()
+		m := newMailbox(actors.MailboxOpt{Capacity: 2, Overflow: actors.OverflowDropOldest})
+		m.push(mailboxMessage(ctx, 1), true, mayWait)
+		m.push(mailboxMessage(ctx, 2), true, mayWait)
+		assert.Equal(t, enqueueDropped, outcomeOf(m.push(mailboxMessage(ctx, 3), true, mayWait)))
+		assert.Equal(t, []any{2, 3}, drainMailbox(m))
+	})
+
+	t.Run("Fail rejects arriving messages", func(t *testing.T) {
+		t.Parallel()
+		m := newMailbox(actors.MailboxOpt{Capacity: 1, Overflow: actors.OverflowFail})
+		m.push(mailboxMessage(ctx, 1), true, mayWait)
+		assert.Equal(t, enqueueRejected, outcomeOf(m.push(mailboxMessage(ctx, 2), true, mayWait)))
+	})
+
+	t.Run("Unbounded ignores capacity", func(t *testing.T) {
+		t.Parallel()
+		m := newMailbox(actors.MailboxOpt{Capacity: 1, Overflow: actors.OverflowUnbounded})
+		for i := 0; i < 4; i++ {
+			assert.Equal(t, enqueued, outcomeOf(m.push(mailboxMessage(ctx, i), true, mayWait)))
+		}
+		assert.Len(t, drainMailbox(m), 4)
+	})
+
+	t.Run("Signals are not bounded", func(t *testing.T) {
+		t.Parallel()
+		m := newMailbox(actors.MailboxOpt{Capacity: 1, Overflow: actors.OverflowFail})
+		m.push(mailboxMessage(ctx, 1), true, mayWait)
+		assert.Equal(t, enqueued, outcomeOf(m.push(mailboxMessage(ctx, 2), false, mayWait)))
+	})
+
+	t.Run("Block waits for the consumer", func(t *testing.T) {
+		t.Parallel()
+		m := newMailbox(actors.MailboxOpt{Capacity: 1, Overflow: actors.OverflowBlock})
+		m.push(mailboxMessage(ctx, 1), true, mayWait)
+		pushed := make(chan enqueueOutcome)
+		go func() {
+			pushed <- outcomeOf(m.push(mailboxMessage(ctx, 2), true, mayWait))
+		}()
+		_, ok := m.pop()
+		require.True(t, ok)
+		assert.Equal(t, enqueued, <-pushed)
+		assert.Equal(t, []any{2}, drainMailbox(m))
+	})
+
+	t.Run("Block refuses senders which may not wait", func(t *testing.T) {
+		t.Parallel()
+		m := newMailbox(actors.MailboxOpt{Capacity: 1, Overflow: actors.OverflowBlock})
+		m.push(mailboxMessage(ctx, 1), true, mayWait)
+		outcome, refused := m.push(mailboxMessage(ctx, 2), true, nil)
+		assert.Equal(t, enqueueFull, outcome)
+		assert.Len(t, refused, 1)
+		assert.Equal(t, []any{1}, drainMailbox(m))
+	})
+
+	t.Run("Close releases blocked senders", func(t *testing.T) {
+		t.Parallel()
+		m := newMailbox(actors.MailboxOpt{Capacity: 1, Overflow: actors.OverflowBlock})
+		m.push(mailboxMessage(ctx, 1), true, mayWait)
+		pushed := make(chan enqueueOutcome)
+		go func() {
+			pushed <- outcomeOf(m.push(mailboxMessage(ctx, 2), true, mayWait))
+		}()
+		m.close()
+		assert.Equal(t, enqueueClosed, <-pushed)
+		_, ok := m.pop()
+		assert.False(t, ok)
+	})
+}
+
+type gatedActor struct {
+	gate    chan struct{}
+	release chan struct{}
+}
+
+func (g *gatedActor) OnMessage(r actors.Runtime, m any) {
+	switch m.(type) {
+	case *actors.Start:
+	case int:
+		if g.gate != nil {
+			close(g.gate)
+			g.gate = nil
+			<-g.release
+		}
+	}
+}
+
+func TestDroppedMessages(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	sys := NewSystem()
+
+	actor := &gatedActor{gate: make(chan struct{}), release: make(chan struct{})}
+	gate := actor.gate
+	pid := sys.Spawn(ctx, actor, actors.MailboxOpt{Capacity: 1, Overflow: actors.OverflowDropNewest})
+	sys.Tell(ctx, pid, 0)
+	<-gate
+
+	sys.Tell(ctx, pid, 1)
+	sys.Tell(ctx, pid, 2)
+	sys.Tell(ctx, pid, 3)
+	close(actor.release)
+
+	dropped, err := DroppedMessages(sys)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), dropped[actors.OverflowDropNewest])
+	assert.Equal(t, uint64(0), dropped[actors.OverflowBlock])
+}
+
+func TestMailboxSystemLane(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+
+	t.Run("Signals are popped before messages", func(t *testing.T) {
+		t.Parallel()
+		m := newMailbox(actors.MailboxOpt{Capacity: 4})
+		m.push(mailboxMessage(ctx, 1), true, mayWait)
+		m.push(mailboxMessage(ctx, 2), false, mayWait)
+		m.pushSystem(mailboxMessage(ctx, 3), mayWait)
+		m.pushSystem(mailboxMessage(ctx, 4), mayWait)
+		assert.Equal(t, 4, m.depth())
+		var order []any
+		for i := 0; i < 4; i++ {
+			message, ok := m.pop()
+			require.True(t, ok)
+			order = append(order, message.next.(*userMessage).m)
+		}
+		assert.Equal(t, []any{3, 4, 1, 2}, order)
+	})
+
+	t.Run("Signals are not subject to the message capacity", func(t *testing.T) {
+		t.Parallel()
+		m := newMailbox(actors.MailboxOpt{Capacity: 1, Overflow: actors.OverflowFail})
+		m.push(mailboxMessage(ctx, 1), true, mayWait)
+		assert.Equal(t, enqueued, outcomeOf(m.pushSystem(mailboxMessage(ctx, 2), mayWait)))
+	})
+
+	t.Run("Full lane blocks senders", func(t *testing.T) {
+		t.Parallel()
+		m := newMailbox(actors.MailboxOpt{SystemCapacity: 1})
+		m.pushSystem(mailboxMessage(ctx, 1), mayWait)
+		assert.Equal(t, enqueued, outcomeOf(m.pushSystem(mailboxMessage(ctx, 2), nil)), "senders which may not wait ignore the capacity")
+		pushed := make(chan enqueueOutcome)
+		go func() {
+			pushed <- outcomeOf(m.pushSystem(mailboxMessage(ctx, 3), mayWait))
+		}()
+		m.pop()
+		m.pop()
+		assert.Equal(t, enqueued, <-pushed)
+	})
+
+	t.Run("Close releases blocked senders", func(t *testing.T) {
+		t.Parallel()
+		m := newMailbox(actors.MailboxOpt{SystemCapacity: 1})
+		m.pushSystem(mailboxMessage(ctx, 1), mayWait)
+		pushed := make(chan enqueueOutcome)
+		go func() {
+			pushed <- outcomeOf(m.pushSystem(mailboxMessage(ctx, 2), mayWait))
+		}()
+		assert.Len(t, m.close(), 1)
+		assert.Equal(t, enqueueClosed, <-pushed)
+	})
+}
+
+func TestTerminatePrecedesQueuedMessages(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	sys := NewSystem()
+	port := sys.NewPort()
+
+	actor := &gatedActor{gate: make(chan struct{}), release: make(chan struct{})}
+	gate := actor.gate
+	pid := sys.Spawn(ctx, actor, actors.MailboxOpt{Capacity: 1000}, actors.MonitorOpt{Tell: port.Pid()})
+	sys.Tell(ctx, pid, 0)
+	<-gate
+	for i := 1; i <= 100; i++ {
+		sys.Tell(ctx, pid, i)
+	}
+	letters, err := ForwardDeadLetters(sys, port.Pid())
+	require.NoError(t, err)
+	defer letters.Off()
+
+	mailbox := sys.(*system).pid2target(pid).(*runtime).mailbox
+	sys.Spawn(ctx, &terminator{target: pid})
+	require.Eventually(t, func() bool {
+		return mailbox.depth() == 101
+	}, time.Second, time.Millisecond, "terminate queued")
+	close(actor.release)
+
+	for i := 1; i <= 100; i++ {
+		letter, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, i, letter.(actors.DeadLetter).Message, "queued messages are never handled")
+	}
+	exit, err := port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, actors.NormalExit{Who: pid, ExitValue: actors.Terminated{}}, exit)
+}
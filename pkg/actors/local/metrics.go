@@ -0,0 +1,143 @@
+package local
+
+import (
+	"context"
+	goruntime "runtime"
+	"time"
+	"weak"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// PathKey is the attribute carrying the named path of the actor a metric was recorded for.
+const PathKey = attribute.Key("actor.path")
+
+// MeterProviderOpt records the metrics of the system through Provider instead of the global provider.
+type MeterProviderOpt struct {
+	Provider metric.MeterProvider
+}
+
+func (m MeterProviderOpt) customizeSystem(s *system) {
+	s.meterProvider = m.Provider
+}
+
+type systemMetrics struct {
+	deadLetters metric.Int64Counter
+	delivered   metric.Int64Counter
+	dropped     metric.Int64Counter
+	tickLatency metric.Float64Histogram
+	panics      metric.Int64Counter
+	exits       metric.Int64Counter
+	//observer reports the gauges of the living actors, unregistered upon shutdown or once the system is collected
+	observer metric.Registration
+}
+
+func newSystemMetrics(s *system) systemMetrics {
+	meter := s.meterProvider.Meter(TracerName)
+	out := systemMetrics{}
+	var err error
+	out.deadLetters, err = meter.Int64Counter("actors.dead_letters", metric.WithDescription("Messages which could not be delivered"))
+	handleMetricError(err)
+	out.delivered, err = meter.Int64Counter("actors.messages.delivered", metric.WithDescription("Messages handled by an actor"))
+	handleMetricError(err)
+	out.dropped, err = meter.Int64Counter("actors.messages.dropped", metric.WithDescription("Messages discarded from the mailbox of an actor"))
+	handleMetricError(err)
+	out.tickLatency, err = meter.Float64Histogram("actors.tick.duration", metric.WithUnit("s"), metric.WithDescription("Time taken by an actor to handle a message"))
+	handleMetricError(err)
+	out.panics, err = meter.Int64Counter("actors.panics", metric.WithDescription("Actors which exited by panicking"))
+	handleMetricError(err)
+	out.exits, err = meter.Int64Counter("actors.normal_exits", metric.WithDescription("Actors which exited normally"))
+	handleMetricError(err)
+	live, err := meter.Int64ObservableGauge("actors.live", metric.WithDescription("Living actors"))
+	handleMetricError(err)
+	depth, err := meter.Int64ObservableGauge("actors.mailbox.depth", metric.WithDescription("Messages waiting within the mailboxes of actors"))
+	handleMetricError(err)
+	//the provider is frequently global, so the callback must not keep a system which was never shutdown reachable
+	observed := weak.Make(s)
+	out.observer, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		if s := observed.Value(); s != nil {
+			s.observeActors(o, live, depth)
+		}
+		return nil
+	}, live, depth)
+	handleMetricError(err)
+	if out.observer != nil {
+		goruntime.AddCleanup(s, unregisterObserver, out.observer)
+	}
+	return out
+}
+
+func unregisterObserver(observer metric.Registration) {
+	if err := observer.Unregister(); err != nil {
+		otel.Handle(err)
+	}
+}
+
+func handleMetricError(err error) {
+	if err != nil {
+		otel.Handle(err)
+	}
+}
+
+// close stops observing the actors of the system.
+func (s *systemMetrics) close() {
+	if s.observer == nil {
+		return
+	}
+	unregisterObserver(s.observer)
+}
+
+// observeActors reports the living actors and their mailbox depths, summed by named path as anonymous actors share a
+// path.
+func (s *system) observeActors(o metric.Observer, live metric.Int64Observable, depth metric.Int64Observable) {
+	type pathTotals struct {
+		live  int64
+		depth int64
+	}
+	totals := make(map[string]*pathTotals)
+	for _, r := range s.runtimes() {
+		path := r.namedPath()
+		total, has := totals[path]
+		if !has {
+			total = &pathTotals{}
+			totals[path] = total
+		}
+		total.live++
+		total.depth += int64(r.mailbox.depth())
+	}
+	for path, total := range totals {
+		attributes := metric.WithAttributes(PathKey.String(path))
+		o.ObserveInt64(live, total.live, attributes)
+		o.ObserveInt64(depth, total.depth, attributes)
+	}
+}
+
+// tickMetrics records the handling of a signal by an actor.
+func (r *runtime) tickMetrics(ctx context.Context, signal runtimeMessage, started time.Time) {
+	attributes := metric.WithAttributes(PathKey.String(r.namedPath()))
+	r.system.metrics.tickLatency.Record(ctx, time.Since(started).Seconds(), attributes)
+	if _, user := signal.(*userMessage); user {
+		r.system.metrics.delivered.Add(ctx, 1, attributes)
+	}
+}
+
+// dropped reports queued messages the actor will not consume.
+func (r *runtime) dropped(ctx context.Context, discarded []tracedDecorator, reason actors.DeadLetterReason) {
+	users := int64(0)
+	for _, message := range discarded {
+		switch next := message.next.(type) {
+		case *userMessage:
+			users++
+		case *timerFired:
+			users++
+			next.discarded(r)
+		}
+		r.deadLetterEntry(ctx, message, reason)
+	}
+	if users > 0 {
+		r.system.metrics.dropped.Add(ctx, users, metric.WithAttributes(PathKey.String(r.namedPath()), attribute.Stringer("reason", reason)))
+	}
+}
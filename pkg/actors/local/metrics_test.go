@@ -0,0 +1,235 @@
+package local
+
+import (
+	"context"
+	goruntime "runtime"
+	"testing"
+	"time"
+	"weak"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// metered replies to the port within a message, exiting upon "exit" and panicking upon "panic".
+type metered struct{}
+
+func (m *metered) OnMessage(r actors.Runtime, msg any) {
+	switch msg := msg.(type) {
+	case actors.Pid:
+		r.Tell(msg, "done")
+	case string:
+		if msg == "exit" {
+			r.Exit(nil)
+		} else if msg == "panic" {
+			panic(msg)
+		}
+	}
+}
+
+// spawnsMetered registers a monitored metered child under each name told, telling report of the child.
+type spawnsMetered struct {
+	report actors.Pid
+}
+
+func (s *spawnsMetered) OnMessage(r actors.Runtime, m any) {
+	if name, ok := m.(string); ok {
+		child := r.Spawn(&metered{}, actors.RegisterOpt{Name: name}, actors.MonitorOpt{Tell: s.report})
+		r.Tell(s.report, child)
+	}
+}
+
+// metricsHarness records the metrics of a system through a manual reader.
+type metricsHarness struct {
+	t      *testing.T
+	reader *sdkmetric.ManualReader
+}
+
+func newMetricsHarness(t *testing.T) (*metricsHarness, actors.System) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	t.Cleanup(func() {
+		require.NoError(t, provider.Shutdown(context.Background()))
+	})
+	return &metricsHarness{t: t, reader: reader}, NewSystem(MeterProviderOpt{Provider: provider})
+}
+
+// find locates the named metric within the collected metrics.
+func (h *metricsHarness) find(name string) metricdata.Metrics {
+	var collected metricdata.ResourceMetrics
+	require.NoError(h.t, h.reader.Collect(context.Background(), &collected))
+	for _, scope := range collected.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if m.Name == name {
+				return m
+			}
+		}
+	}
+	h.t.Fatalf("metric %q was not recorded", name)
+	return metricdata.Metrics{}
+}
+
+// value is the total of the named counter or gauge for the actor at path.
+func (h *metricsHarness) value(name string, path string) int64 {
+	var points []metricdata.DataPoint[int64]
+	switch data := h.find(name).Data.(type) {
+	case metricdata.Sum[int64]:
+		points = data.DataPoints
+	case metricdata.Gauge[int64]:
+		points = data.DataPoints
+	default:
+		h.t.Fatalf("unexpected data %#v for %q", data, name)
+	}
+	total := int64(0)
+	for _, point := range points {
+		if value, _ := point.Attributes.Value(PathKey); value.AsString() == path {
+			total += point.Value
+		}
+	}
+	return total
+}
+
+// ticks is the number of ticks recorded for the actor at path.
+func (h *metricsHarness) ticks(path string) uint64 {
+	data := h.find("actors.tick.duration").Data.(metricdata.Histogram[float64])
+	for _, point := range data.DataPoints {
+		if value, _ := point.Attributes.Value(PathKey); value.AsString() == path {
+			return point.Count
+		}
+	}
+	return 0
+}
+
+func TestSystemMetrics(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	h, sys := newMetricsHarness(t)
+	port := sys.NewPort()
+	parent := sys.Spawn(ctx, &spawnsMetered{report: port.Pid()})
+
+	spawnChild := func(name string) actors.Pid {
+		sys.Tell(ctx, parent, name)
+		child, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		return child.(actors.Pid)
+	}
+	worker := spawnChild("worker")
+	sys.Tell(ctx, worker, port.Pid())
+	_, err := port.ReceiveWith(ctx)
+	require.NoError(t, err)
+
+	//recorded once the tick completes, racing the reply
+	assert.Eventually(t, func() bool {
+		return h.value("actors.messages.delivered", "/worker") == 2 && h.ticks("/worker") >= 2
+	}, time.Second, time.Millisecond, "start and reply")
+	assert.Equal(t, int64(1), h.value("actors.live", "/worker"))
+	assert.Equal(t, int64(0), h.value("actors.mailbox.depth", "/worker"))
+
+	sys.Tell(ctx, worker, "panic")
+	exit, err := port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	require.IsType(t, actors.PanicExit{}, exit)
+	assert.Equal(t, int64(1), h.value("actors.panics", "/worker"))
+
+	leaving := spawnChild("leaving")
+	sys.Tell(ctx, leaving, "exit")
+	exit, err = port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	require.IsType(t, actors.NormalExit{}, exit)
+	assert.Equal(t, int64(1), h.value("actors.normal_exits", "/leaving"))
+	assert.Equal(t, int64(0), h.value("actors.live", "/leaving"))
+}
+
+func TestDroppedMessageMetrics(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	h, sys := newMetricsHarness(t)
+
+	actor := &gatedActor{gate: make(chan struct{}), release: make(chan struct{})}
+	gate := actor.gate
+	pid := sys.Spawn(ctx, actor, actors.MailboxOpt{Capacity: 1, Overflow: actors.OverflowDropNewest})
+	sys.Tell(ctx, pid, 0)
+	<-gate
+	sys.Tell(ctx, pid, 1)
+	sys.Tell(ctx, pid, 2)
+	assert.Equal(t, int64(1), h.value("actors.mailbox.depth", "/"))
+	close(actor.release)
+
+	dropped := h.find("actors.messages.dropped").Data.(metricdata.Sum[int64])
+	require.Len(t, dropped.DataPoints, 1)
+	point := dropped.DataPoints[0]
+	assert.Equal(t, int64(1), point.Value)
+	assert.True(t, point.Attributes.HasValue(PathKey))
+	reason, _ := point.Attributes.Value(attribute.Key("reason"))
+	assert.Equal(t, actors.DeadLetterMailboxOverflow.String(), reason.AsString())
+	assert.Equal(t, int64(1), h.value("actors.dead_letters", "/"))
+}
+
+// renamingParent spawns an anonymous child upon start, registering the child under each name told and unregistering
+// it upon an empty name.
+type renamingParent struct {
+	report actors.Pid
+	child  actors.Pid
+	named  string
+}
+
+func (p *renamingParent) OnMessage(r actors.Runtime, m any) {
+	switch msg := m.(type) {
+	case *actors.Start:
+		p.child = r.Spawn(&metered{})
+		r.Tell(p.report, p.child)
+	case string:
+		if msg == "" {
+			r.Unregister(p.named)
+		} else {
+			r.Register(msg, p.child)
+		}
+		p.named = msg
+		r.Tell(p.report, msg)
+	}
+}
+
+func TestNamedPathCache(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	sys := NewSystem()
+	port := sys.NewPort()
+	parent := sys.Spawn(ctx, &renamingParent{report: port.Pid()})
+	child, err := port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	r := sys.(*system).pid2target(child.(actors.Pid)).(*runtime)
+	assert.Equal(t, "/<anonymous>", r.namedPath())
+
+	rename := func(name string) {
+		sys.Tell(ctx, parent, name)
+		_, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+	}
+	rename("named")
+	assert.Equal(t, "/named", r.namedPath(), "registration invalidates the cached path")
+	rename("")
+	assert.Equal(t, "/<anonymous>", r.namedPath(), "unregistration invalidates the cached path")
+}
+
+func TestUnreferencedSystemsAreCollected(t *testing.T) {
+	t.Parallel()
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	t.Cleanup(func() {
+		require.NoError(t, provider.Shutdown(context.Background()))
+	})
+	abandoned := func() weak.Pointer[system] {
+		return weak.Make(NewSystem(MeterProviderOpt{Provider: provider}).(*system))
+	}()
+
+	assert.Eventually(t, func() bool {
+		goruntime.GC()
+		return abandoned.Value() == nil
+	}, time.Second, time.Millisecond, "the gauge callback does not retain the system")
+	var collected metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &collected))
+}
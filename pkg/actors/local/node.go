@@ -0,0 +1,380 @@
+package local
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NodeOpt assigns the node identity of the system.  Every Pid spawned by the system carries the identity and Pids
+// carrying other identities are routed over the links established through Listen and Connect.
+type NodeOpt struct {
+	ID uint64
+}
+
+func (n NodeOpt) customizeSystem(s *system) {
+	s.node = n.ID
+}
+
+// CodecOpt encodes messages sent to other nodes through the given registry instead of encoding/gob.
+type CodecOpt struct {
+	Registry *actors.CodecRegistry
+}
+
+func (c CodecOpt) customizeSystem(s *system) {
+	s.payloads = registryPayloads{registry: c.Registry}
+}
+
+// ForeignSystemError indicates an operation specific to local systems was attempted against another implementation.
+type ForeignSystemError struct {
+	System actors.System
+}
+
+func (f *ForeignSystemError) Error() string {
+	return fmt.Sprintf("%T is not a local actor system", f.System)
+}
+
+// NodeConflictError indicates both ends of a link claim the same node identity.
+type NodeConflictError struct {
+	Node uint64
+}
+
+func (n *NodeConflictError) Error() string {
+	return fmt.Sprintf("peer claims the same node identity %d", n.Node)
+}
+
+func asLocal(sys actors.System) (*system, error) {
+	if s, ok := sys.(*system); ok {
+		return s, nil
+	}
+	return nil, &ForeignSystemError{System: sys}
+}
+
+// Listen accepts links from other nodes on the given address until the returned listener is closed.
+func Listen(ctx context.Context, sys actors.System, network, address string) (net.Listener, error) {
+	s, err := asLocal(sys)
+	if err != nil {
+		return nil, err
+	}
+	var config net.ListenConfig
+	listener, err := config.Listen(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	go s.accept(listener)
+	return listener, nil
+}
+
+// Connect establishes a link with the node listening at the given address.  Returns once both nodes have exchanged
+// identities.
+func Connect(ctx context.Context, sys actors.System, network, address string) error {
+	s, err := asLocal(sys)
+	if err != nil {
+		return err
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return err
+	}
+	if err := s.handshake(ctx, conn); err != nil {
+		_ = conn.Close()
+		return err
+	}
+	return nil
+}
+
+// remoteWatch is a monitor or link held by a local actor against an actor on the linked node.
+type remoteWatch struct {
+	watched actors.Pid
+	watcher actors.Pid
+	momento any
+	linked  bool
+}
+
+type nodeLink struct {
+	node    uint64
+	conn    net.Conn
+	writing sync.Mutex
+	out     *gob.Encoder
+	in      *gob.Decoder
+	//watches is guarded by the system's linkLock
+	watches []remoteWatch
+}
+
+func (l *nodeLink) send(frame *wireFrame) error {
+	l.writing.Lock()
+	defer l.writing.Unlock()
+	return l.out.Encode(frame)
+}
+
+func (s *system) accept(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			if err := s.handshake(context.Background(), conn); err != nil {
+				_ = conn.Close()
+			}
+		}()
+	}
+}
+
+func (s *system) handshake(ctx context.Context, conn net.Conn) error {
+	if deadline, has := ctx.Deadline(); has {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+	link := &nodeLink{
+		conn: conn,
+		out:  gob.NewEncoder(conn),
+		in:   gob.NewDecoder(conn),
+	}
+	if err := link.out.Encode(wireHello{Node: s.node}); err != nil {
+		return err
+	}
+	var peer wireHello
+	if err := link.in.Decode(&peer); err != nil {
+		return err
+	}
+	if peer.Node == s.node {
+		return &NodeConflictError{Node: peer.Node}
+	}
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return err
+	}
+	link.node = peer.Node
+
+	s.linkLock.Lock()
+	previous := s.links[peer.Node]
+	s.links[peer.Node] = link
+	s.linkLock.Unlock()
+	if previous != nil {
+		s.dropLink(ctx, previous)
+	}
+	go s.read(link)
+	return nil
+}
+
+func (s *system) read(link *nodeLink) {
+	defer s.dropLink(context.Background(), link)
+	for {
+		var frame wireFrame
+		if err := link.in.Decode(&frame); err != nil {
+			return
+		}
+		s.receiveFrame(link, &frame)
+	}
+}
+
+func (s *system) linkFor(node uint64) *nodeLink {
+	s.linkLock.Lock()
+	defer s.linkLock.Unlock()
+	return s.links[node]
+}
+
+// dropLink closes the link, notifying local watchers of actors on the remote node as those actors are no longer
+// reachable.
+func (s *system) dropLink(ctx context.Context, link *nodeLink) {
+	s.linkLock.Lock()
+	if s.links[link.node] == link {
+		delete(s.links, link.node)
+	}
+	watches := link.watches
+	link.watches = nil
+	s.linkLock.Unlock()
+
+	_ = link.conn.Close()
+	for _, w := range watches {
+		if w.linked {
+			s.execute(ctx, w.watcher, &linkExitSignal{from: w.watched, reason: actors.NoProcess{}, abnormal: true})
+		} else {
+			s.Tell(ctx, w.watcher, actors.NewPanicExit(w.watched, w.momento))
+		}
+	}
+}
+
+func (s *system) isRemote(p actors.Pid) bool {
+	return p.Node != s.node
+}
+
+// tellRemote forwards m to the node hosting p.  Messages which may not be encoded panic within a sending actor, ensuring
+// the problem surfaces at the point of the Tell instead of being silently lost.  Other senders, such as ports and
+// timers, are not actors which may fail so the message is dead lettered instead.  Exit notifications are produced by
+// the runtime itself and drop values which may not be encoded, so are only recorded if the frame still may not be built.
+func (s *system) tellRemote(ctx context.Context, p actors.Pid, m any) {
+	frame, err := messageFrame(s.payloads, m)
+	if err != nil {
+		span := trace.SpanFromContext(ctx)
+		span.AddEvent("unencodable-message", trace.WithAttributes(attribute.Stringer("target", p)))
+		span.RecordError(err)
+		switch m.(type) {
+		case actors.NormalExit, actors.PanicExit:
+			return
+		}
+		if withinTick(ctx) {
+			panic(err)
+		}
+		s.deadLetter(ctx, "", actors.DeadLetter{Target: p, Sender: senderFrom(ctx), Message: m, Reason: actors.DeadLetterUnencodable})
+		return
+	}
+	if !s.sendFrame(ctx, p, &frame) {
+		s.deadLetter(ctx, "", actors.DeadLetter{Target: p, Sender: senderFrom(ctx), Message: m, Reason: actors.DeadLetterNoRoute})
+	}
+}
+
+func (s *system) executeRemote(ctx context.Context, target actors.Pid, action runtimeMessage) {
+	span := trace.SpanFromContext(ctx)
+	signal, ok := action.(remoteSignal)
+	if !ok {
+		span.AddEvent("unsupported-remote-signal", trace.WithAttributes(attribute.Stringer("pid", target), attribute.String("signal", action.name())))
+		return
+	}
+	frame, err := signal.frame(s.payloads)
+	if err != nil {
+		span.AddEvent("unencodable-signal", trace.WithAttributes(attribute.Stringer("pid", target), attribute.String("signal", action.name())))
+		span.RecordError(err)
+		return
+	}
+
+	switch a := action.(type) {
+	case *startMonitoring:
+		if !s.watchRemote(target, remoteWatch{watched: target, watcher: a.listener, momento: a.what}) {
+			s.Tell(ctx, a.listener, actors.NewPanicExit(target, a.what))
+			return
+		}
+	case *stopMonitoring:
+		s.unwatchRemote(target, a.listener, false)
+	case *linkSignal:
+		if !s.watchRemote(target, remoteWatch{watched: target, watcher: a.with, linked: true}) {
+			s.execute(ctx, a.with, &linkExitSignal{from: target, reason: actors.NoProcess{}, abnormal: true})
+			return
+		}
+	case *unlinkSignal:
+		s.unwatchRemote(target, a.with, true)
+	}
+	s.sendFrame(ctx, target, &frame)
+}
+
+func (s *system) watchRemote(target actors.Pid, watch remoteWatch) bool {
+	s.linkLock.Lock()
+	defer s.linkLock.Unlock()
+	link := s.links[target.Node]
+	if link == nil {
+		return false
+	}
+	link.watches = append(link.watches, watch)
+	return true
+}
+
+func (s *system) unwatchRemote(watched actors.Pid, watcher actors.Pid, linked bool) {
+	s.linkLock.Lock()
+	defer s.linkLock.Unlock()
+	link := s.links[watched.Node]
+	if link == nil {
+		return
+	}
+	remaining := link.watches[:0]
+	for _, w := range link.watches {
+		if w.watched != watched || w.watcher != watcher || w.linked != linked {
+			remaining = append(remaining, w)
+		}
+	}
+	link.watches = remaining
+}
+
+// sendFrame writes the frame to the node hosting to, returning false if no link to the node exists or the frame could
+// not be written, in which case the link is dropped.
+func (s *system) sendFrame(ctx context.Context, to actors.Pid, frame *wireFrame) bool {
+	span := trace.SpanFromContext(ctx)
+	link := s.linkFor(to.Node)
+	if link == nil {
+		span.AddEvent("no-route", trace.WithAttributes(attribute.Stringer("target", to)))
+		return false
+	}
+	frame.To = to
+	frame.inject(ctx)
+	if err := link.send(frame); err != nil {
+		span.RecordError(err)
+		s.dropLink(ctx, link)
+		return false
+	}
+	return true
+}
+
+func (s *system) receiveFrame(link *nodeLink, frame *wireFrame) {
+	//the link must never block or fail on behalf of a single target as all traffic from the node would stall
+	ctx, span := tracer.Start(withDetached(frame.extract(context.Background())), "remote frame", trace.WithSpanKind(trace.SpanKindConsumer))
+	defer span.End()
+	span.SetAttributes(attribute.Int64("node", int64(link.node)), attribute.Stringer("target", frame.To))
+
+	if err := s.dispatchFrame(ctx, frame); err != nil {
+		span.AddEvent("undecodable-frame")
+		span.RecordError(err)
+	}
+}
+
+func (s *system) dispatchFrame(ctx context.Context, frame *wireFrame) error {
+	switch frame.Kind {
+	case frameTell:
+		value, err := s.payloads.decode(frame.Payload)
+		if err != nil {
+			return err
+		}
+		s.Tell(ctx, frame.To, value)
+	case frameNormalExit:
+		exitValue, err := s.payloads.decode(frame.Payload)
+		if err != nil {
+			return err
+		}
+		momento, err := s.payloads.decode(frame.Momento)
+		if err != nil {
+			return err
+		}
+		s.unwatchRemote(frame.Peer, frame.To, false)
+		s.Tell(ctx, frame.To, actors.NormalExit{Who: frame.Peer, ExitValue: exitValue, Momento: momento})
+	case framePanicExit:
+		momento, err := s.payloads.decode(frame.Momento)
+		if err != nil {
+			return err
+		}
+		s.unwatchRemote(frame.Peer, frame.To, false)
+		s.Tell(ctx, frame.To, actors.NewPanicExit(frame.Peer, momento))
+	case frameMonitor:
+		momento, err := s.payloads.decode(frame.Momento)
+		if err != nil {
+			return err
+		}
+		s.execute(ctx, frame.To, &startMonitoring{listener: frame.Peer, what: momento})
+	case frameUnmonitor:
+		s.execute(ctx, frame.To, &stopMonitoring{listener: frame.Peer})
+	case frameTerminate:
+		s.execute(ctx, frame.To, &terminateSignal{})
+	case frameLink:
+		s.watchRemote(frame.Peer, remoteWatch{watched: frame.Peer, watcher: frame.To, linked: true})
+		s.execute(ctx, frame.To, &linkSignal{with: frame.Peer})
+	case frameUnlink:
+		s.unwatchRemote(frame.Peer, frame.To, true)
+		s.execute(ctx, frame.To, &unlinkSignal{with: frame.Peer})
+	case frameLinkExit:
+		reason, err := s.payloads.decode(frame.Payload)
+		if err != nil {
+			return err
+		}
+		s.unwatchRemote(frame.Peer, frame.To, true)
+		s.execute(ctx, frame.To, &linkExitSignal{from: frame.Peer, reason: reason, abnormal: frame.Abnormal})
+	default:
+		return fmt.Errorf("unknown frame kind %d", frame.Kind)
+	}
+	return nil
+}
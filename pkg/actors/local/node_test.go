@@ -0,0 +1,260 @@
+package local
+
+import (
+	"context"
+	"encoding/gob"
+	"testing"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type remoteEcho struct {
+	Reply actors.Pid
+	Text  string
+}
+
+type remoteGiveUp struct{}
+
+// remoteExit asks the echoActor to exit with a value which may not be encoded.
+type remoteExit struct{}
+
+type unencodableExit struct {
+	callback func()
+}
+
+type echoActor struct{}
+
+func (e *echoActor) OnMessage(r actors.Runtime, m any) {
+	switch msg := m.(type) {
+	case remoteEcho:
+		r.Tell(msg.Reply, msg.Text+" echo")
+	case remoteGiveUp:
+		panic("giving up")
+	case remoteExit:
+		r.Exit(unencodableExit{callback: func() {}})
+	}
+}
+
+type remoteWatcher struct {
+	target actors.Pid
+	report actors.Pid
+}
+
+func (w *remoteWatcher) OnMessage(r actors.Runtime, m any) {
+	switch m.(type) {
+	case *actors.Start:
+		r.Monitor2(w.target, r.Self())
+		r.Tell(w.report, "watching")
+	default:
+		r.Tell(w.report, m)
+	}
+}
+
+func init() {
+	gob.Register(remoteEcho{})
+	gob.Register(remoteGiveUp{})
+	gob.Register(remoteExit{})
+}
+
+func linkedSystems(t *testing.T, ctx context.Context, opts ...SystemOpts) (actors.System, actors.System) {
+	first := NewSystem(append(opts, NodeOpt{ID: 1})...)
+	second := NewSystem(append(opts, NodeOpt{ID: 2})...)
+
+	listener, err := Listen(ctx, first, "tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+	require.NoError(t, Connect(ctx, second, "tcp", listener.Addr().String()))
+	return first, second
+}
+
+func TestRemoteTell(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	first, second := linkedSystems(t, ctx)
+
+	echo := first.Spawn(ctx, &echoActor{})
+	assert.Equal(t, uint64(1), echo.Node)
+
+	port := second.NewPort()
+	port.Tell(ctx, echo, remoteEcho{Reply: port.Pid(), Text: "hello"})
+	reply, err := port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "hello echo", reply)
+}
+
+func TestRemoteMonitor(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	first, second := linkedSystems(t, ctx)
+
+	target := first.Spawn(ctx, &echoActor{})
+	port := second.NewPort()
+	second.Spawn(ctx, &remoteWatcher{target: target, report: port.Pid()})
+	ready, err := port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "watching", ready)
+
+	port.Tell(ctx, target, remoteGiveUp{})
+	exit, err := port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	if assert.IsType(t, actors.PanicExit{}, exit) {
+		assert.Equal(t, target, exit.(actors.PanicExit).Who)
+	}
+}
+
+func TestRemoteMonitorUnencodableExit(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	first, second := linkedSystems(t, ctx)
+
+	target := first.Spawn(ctx, &echoActor{})
+	port := second.NewPort()
+	second.Spawn(ctx, &remoteWatcher{target: target, report: port.Pid()})
+	ready, err := port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "watching", ready)
+
+	port.Tell(ctx, target, remoteExit{})
+	exit, err := port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, actors.NormalExit{Who: target}, exit, "delivered without the exit value")
+}
+
+func TestRemoteTellFullMailbox(t *testing.T) {
+	t.Parallel()
+	for _, policy := range []actors.OverflowPolicy{actors.OverflowBlock, actors.OverflowFail} {
+		t.Run(policy.String(), func(t *testing.T) {
+			t.Parallel()
+			ctx := t.Context()
+			first, second := linkedSystems(t, ctx)
+			letters := first.NewPort()
+			_, err := ForwardDeadLetters(first, letters.Pid())
+			require.NoError(t, err)
+
+			actor := &gatedActor{gate: make(chan struct{}), release: make(chan struct{})}
+			defer close(actor.release)
+			gate := actor.gate
+			full := first.Spawn(ctx, actor, actors.MailboxOpt{Capacity: 1, Overflow: policy})
+			echo := first.Spawn(ctx, &echoActor{})
+			first.Tell(ctx, full, 0)
+			<-gate
+			first.Tell(ctx, full, 1)
+
+			port := second.NewPort()
+			port.Tell(ctx, full, 2)
+			port.Tell(ctx, echo, remoteEcho{Reply: port.Pid(), Text: "still"})
+			reply, err := port.ReceiveWith(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, "still echo", reply, "link continues to deliver")
+
+			letter, err := letters.ReceiveWith(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, actors.DeadLetter{Target: full, Message: 2, Reason: actors.DeadLetterMailboxFull}, letter)
+		})
+	}
+}
+
+func TestRemoteTellFullPort(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	first, second := linkedSystems(t, ctx)
+	letters := first.NewPort()
+	_, err := ForwardDeadLetters(first, letters.Pid())
+	require.NoError(t, err)
+
+	full := first.NewPort()
+	for i := range cap(full.(*port).mailbox) {
+		first.Tell(ctx, full.Pid(), i)
+	}
+	echo := first.Spawn(ctx, &echoActor{})
+
+	port := second.NewPort()
+	port.Tell(ctx, full.Pid(), "overflow")
+	port.Tell(ctx, echo, remoteEcho{Reply: port.Pid(), Text: "still"})
+	reply, err := port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "still echo", reply, "link continues to deliver")
+
+	letter, err := letters.ReceiveWith(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, actors.DeadLetter{Target: full.Pid(), Message: "overflow", Reason: actors.DeadLetterMailboxFull}, letter)
+}
+
+func TestRemoteTellBrokenLink(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	first, second := linkedSystems(t, ctx)
+	letters := second.NewPort()
+	_, err := ForwardDeadLetters(second, letters.Pid())
+	require.NoError(t, err)
+
+	echo := first.Spawn(ctx, &echoActor{})
+	require.NoError(t, second.(*system).linkFor(1).conn.Close())
+	port := second.NewPort()
+	port.Tell(ctx, echo, remoteEcho{Reply: port.Pid(), Text: "lost"})
+
+	letter, err := letters.ReceiveWith(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, actors.DeadLetter{Target: echo, Sender: port.Pid(), Message: remoteEcho{Reply: port.Pid(), Text: "lost"}, Reason: actors.DeadLetterNoRoute}, letter)
+}
+
+func TestNodeConflict(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	first := NewSystem(NodeOpt{ID: 3})
+	second := NewSystem(NodeOpt{ID: 3})
+
+	listener, err := Listen(ctx, first, "tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+	err = Connect(ctx, second, "tcp", listener.Addr().String())
+	var conflict *NodeConflictError
+	assert.ErrorAs(t, err, &conflict)
+}
+
+func TestRemoteCodecRegistry(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	registry := actors.NewCodecRegistry()
+	require.NoError(t, actors.RegisterMessage[remoteEcho](registry, "remote-echo", actors.JSONCodec))
+	require.NoError(t, actors.RegisterMessage[string](registry, "string", actors.JSONCodec))
+	first, second := linkedSystems(t, ctx, CodecOpt{Registry: registry})
+
+	echo := first.Spawn(ctx, &echoActor{})
+	port := second.NewPort()
+
+	t.Run("Registered messages are delivered", func(t *testing.T) {
+		port.Tell(ctx, echo, remoteEcho{Reply: port.Pid(), Text: "json"})
+		reply, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "json echo", reply)
+	})
+
+	t.Run("Unregistered messages fail the telling actor", func(t *testing.T) {
+		watcher := second.NewPort()
+		sender := second.Spawn(ctx, &teller{target: echo, message: remoteGiveUp{}}, actors.MonitorOpt{Tell: watcher.Pid()})
+		exit, err := watcher.ReceiveWith(ctx)
+		require.NoError(t, err)
+		if assert.IsType(t, actors.PanicExit{}, exit) {
+			assert.Equal(t, sender, exit.(actors.PanicExit).Who)
+		}
+	})
+
+	t.Run("Unregistered messages from outside an actor are dead lettered", func(t *testing.T) {
+		letters := second.NewPort()
+		_, err := ForwardDeadLetters(second, letters.Pid())
+		require.NoError(t, err)
+		assert.NotPanics(t, func() {
+			port.Tell(ctx, echo, remoteGiveUp{})
+		})
+		letter, err := letters.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, actors.DeadLetter{Target: echo, Sender: port.Pid(), Message: remoteGiveUp{}, Reason: actors.DeadLetterUnencodable}, letter)
+	})
+}
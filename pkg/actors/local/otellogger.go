@@ -3,6 +3,8 @@ package local
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/meschbach/go-junk-bucket/pkg/actors"
 	"go.opentelemetry.io/otel/attribute"
@@ -11,7 +13,8 @@ import (
 )
 
 type otelLogger struct {
-	span trace.Span
+	span  trace.Span
+	attrs []any
 }
 
 func (o *otelLogger) Info(format string, args ...any) {
@@ -41,10 +44,35 @@ func (o *otelLogger) Error(format string, args ...any) {
 	o.span.SetStatus(codes.Error, "error")
 }
 
+// Enabled is true while the span of the tick is recording.
+func (o *otelLogger) Enabled(level slog.Level) bool {
+	return o.span.IsRecording()
+}
+
+// Log adds msg to the span as an event named after level, with each attribute rendered as a string.
+func (o *otelLogger) Log(level slog.Level, msg string, args ...any) {
+	if !o.span.IsRecording() {
+		return
+	}
+	record := slog.NewRecord(time.Time{}, level, msg, 0)
+	record.Add(append(append([]any{}, o.attrs...), args...)...)
+	attributes := []attribute.KeyValue{attribute.String("message", msg)}
+	record.Attrs(func(attr slog.Attr) bool {
+		attributes = append(attributes, attribute.String(attr.Key, attr.Value.Resolve().String()))
+		return true
+	})
+	o.span.AddEvent(levelName(level), trace.WithAttributes(attributes...))
+}
+
+func (o *otelLogger) With(args ...any) actors.StructuredLogger {
+	return &otelLogger{span: o.span, attrs: append(append([]any{}, o.attrs...), args...)}
+}
+
+// OTELLoggingStrategy records messages as events of the span of the current tick.
 type OTELLoggingStrategy struct {
 }
 
-func (o *OTELLoggingStrategy) buildLogger(ctx context.Context, who actors.Pid) actors.Logger {
+func (o *OTELLoggingStrategy) buildLogger(ctx context.Context, who actors.Pid, path func() string) actors.Logger {
 	span := trace.SpanFromContext(ctx)
 	return &otelLogger{span: span}
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -24,6 +25,10 @@ type port struct {
 	mailbox chan any
 	theater *system
 	state   uint32
+	//sending is held for reading by senders so the mailbox is only closed once no send is in flight
+	sending sync.RWMutex
+	//closing releases senders blocked on a full mailbox when the port is closed
+	closing chan struct{}
 }
 
 func newPort(self actors.Pid, theater *system) *port {
@@ -32,6 +37,7 @@ func newPort(self actors.Pid, theater *system) *port {
 		mailbox: make(chan any, 16),
 		theater: theater,
 		state:   portOpen,
+		closing: make(chan struct{}),
 	}
 }
 
@@ -59,6 +65,13 @@ func (p *port) ReceiveTimeout(wait time.Duration) (any, error) {
 }
 
 func (p *port) ReceiveWith(ctx context.Context) (any, error) {
+	select {
+	case value := <-p.mailbox:
+		return value, nil
+	default:
+	}
+	resume := blocking(ctx)
+	defer resume()
 	select {
 	case <-ctx.Done():
 		return nil, &MessageTimeoutError{
@@ -72,8 +85,37 @@ func (p *port) ReceiveWith(ctx context.Context) (any, error) {
 
 // TODO: tracing -- is it feasible to do here?
 func (p *port) told(from context.Context, m any) {
-	if v := atomic.LoadUint32(&p.state); v == portOpen {
-		p.mailbox <- m
+	switch p.send(m, senderBlocker(from)) {
+	case enqueueClosed:
+		p.theater.deadLetter(from, "", actors.DeadLetter{Target: p.self, Sender: senderFrom(from), Message: m, Reason: actors.DeadLetterPortClosed})
+	case enqueueFull:
+		p.theater.deadLetter(from, "", actors.DeadLetter{Target: p.self, Sender: senderFrom(from), Message: m, Reason: actors.DeadLetterMailboxFull})
+	}
+}
+
+// send places m within the mailbox, waiting for room unless the sender may not wait.  Replies arriving after an Ask
+// gives up race the Close of the port, so the check and the send happen under the sending lock.
+func (p *port) send(m any, block blocker) enqueueOutcome {
+	p.sending.RLock()
+	defer p.sending.RUnlock()
+	if atomic.LoadUint32(&p.state) != portOpen {
+		return enqueueClosed
+	}
+	select {
+	case p.mailbox <- m:
+		return enqueued
+	default:
+	}
+	if block == nil {
+		return enqueueFull
+	}
+	resume := block()
+	defer resume()
+	select {
+	case p.mailbox <- m:
+		return enqueued
+	case <-p.closing:
+		return enqueueClosed
 	}
 }
 
@@ -85,11 +127,11 @@ func (p *port) Tell(ctx context.Context, who actors.Pid, what any) {
 	span.SetAttributes(attribute.String("pid", p.self.String()))
 	span.SetAttributes(attribute.String("telling", who.String()))
 	defer span.End()
-	p.theater.Tell(portContext, who, what)
+	p.theater.Tell(withSender(portContext, p.self), who, what)
 }
 
 func (p *port) Log(ctx context.Context) actors.Logger {
-	return p.theater.loggingStrategy.buildLogger(ctx, p.self)
+	return p.theater.loggingStrategy.buildLogger(ctx, p.self, nil)
 }
 
 func (p *port) Close(ctx context.Context) {
@@ -97,6 +139,9 @@ func (p *port) Close(ctx context.Context) {
 		return
 	}
 	p.theater.removeTarget(p.self)
+	close(p.closing)
+	p.sending.Lock()
+	defer p.sending.Unlock()
 	close(p.mailbox)
 }
 
@@ -0,0 +1,235 @@
+package local
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/meschbach/go-junk-bucket/pkg/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type doubleAction struct{}
+
+func (d *doubleAction) Invoke(bif actors.Runtime, state int) int {
+	return state * 2
+}
+
+type askWrongType struct {
+	replyTo actors.ReplyTo
+}
+
+type askIgnored struct{}
+
+type serviceActor struct {
+	state int
+}
+
+func (s *serviceActor) OnMessage(r actors.Runtime, m any) {
+	switch msg := m.(type) {
+	case actors.RpcCall[int, int]:
+		msg.Perform(r, s.state)
+	case askWrongType:
+		msg.replyTo.Reply(r, "not a number")
+	case askValue:
+		msg.replyTo.Reply(r, s.state)
+	case remoteGiveUp:
+		panic("giving up")
+	}
+}
+
+// lateReplier holds requests until told to reply.
+type lateReplier struct {
+	pending []actors.ReplyTo
+}
+
+func (l *lateReplier) OnMessage(r actors.Runtime, m any) {
+	switch msg := m.(type) {
+	case askValue:
+		l.pending = append(l.pending, msg.replyTo)
+	case string:
+		for _, replyTo := range l.pending {
+			replyTo.Reply(r, 1)
+		}
+	}
+}
+
+// caller performs the call on start, reporting the result and error.
+type caller struct {
+	perform func(r actors.Runtime) (any, error)
+	report  actors.Pid
+}
+
+type callResult struct {
+	value any
+	err   error
+}
+
+func (c *caller) OnMessage(r actors.Runtime, m any) {
+	if _, ok := m.(*actors.Start); ok {
+		value, err := c.perform(r)
+		r.Tell(c.report, callResult{value: value, err: err})
+	}
+}
+
+func performCall(t *testing.T, perform func(r actors.Runtime) (any, error)) callResult {
+	ctx := t.Context()
+	sys := NewSystem()
+	port := sys.NewPort()
+	sys.Spawn(ctx, &caller{perform: perform, report: port.Pid()})
+	result, err := port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	return result.(callResult)
+}
+
+func TestCall(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Successful calls reply with the result", func(t *testing.T) {
+		t.Parallel()
+		result := performCall(t, func(r actors.Runtime) (any, error) {
+			target := r.Spawn(&serviceActor{state: 21})
+			return actors.Call[int, int](r.Context(), r, target, &doubleAction{})
+		})
+		require.NoError(t, result.err)
+		assert.Equal(t, 42, result.value)
+	})
+
+	t.Run("Slow targets time out", func(t *testing.T) {
+		t.Parallel()
+		result := performCall(t, func(r actors.Runtime) (any, error) {
+			target := r.Spawn(&serviceActor{})
+			ctx, done := context.WithTimeout(r.Context(), 5*time.Millisecond)
+			defer done()
+			return actors.Ask[int](ctx, r, target, func(replyTo actors.ReplyTo) any {
+				return askIgnored{}
+			})
+		})
+		var timeout *actors.CallTimeoutError
+		assert.ErrorAs(t, result.err, &timeout)
+	})
+
+	t.Run("Replies after a timeout are dead lettered", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		letters := sys.NewPort()
+		_, err := ForwardDeadLetters(sys, letters.Pid())
+		require.NoError(t, err)
+		port := sys.NewPort()
+		sys.Spawn(ctx, &caller{perform: func(r actors.Runtime) (any, error) {
+			target := r.Spawn(&lateReplier{})
+			ctx, done := context.WithTimeout(r.Context(), 5*time.Millisecond)
+			defer done()
+			_, err := actors.Ask[int](ctx, r, target, func(replyTo actors.ReplyTo) any {
+				return askValue{replyTo: replyTo}
+			})
+			r.Tell(target, "reply")
+			return nil, err
+		}, report: port.Pid()})
+		result, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		var timeout *actors.CallTimeoutError
+		assert.ErrorAs(t, result.(callResult).err, &timeout)
+
+		for {
+			letter, err := letters.ReceiveWith(ctx)
+			require.NoError(t, err)
+			if reply, ok := letter.(actors.DeadLetter).Message.(actors.Reply); ok {
+				assert.Equal(t, 1, reply.Value)
+				break
+			}
+		}
+	})
+
+	t.Run("Targets exiting are reported", func(t *testing.T) {
+		t.Parallel()
+		result := performCall(t, func(r actors.Runtime) (any, error) {
+			target := r.Spawn(&serviceActor{})
+			return actors.Ask[int](r.Context(), r, target, func(replyTo actors.ReplyTo) any {
+				return remoteGiveUp{}
+			})
+		})
+		var exited *actors.TargetExitedError
+		if assert.ErrorAs(t, result.err, &exited) {
+			assert.True(t, exited.Panicked)
+		}
+	})
+
+	t.Run("Targets which do not exist are reported", func(t *testing.T) {
+		t.Parallel()
+		result := performCall(t, func(r actors.Runtime) (any, error) {
+			return actors.Call[int, int](r.Context(), r, actors.Pid{Process: 9999}, &doubleAction{})
+		})
+		var exited *actors.TargetExitedError
+		if assert.ErrorAs(t, result.err, &exited) {
+			assert.Equal(t, actors.NoProcess{}, exited.ExitValue)
+		}
+	})
+
+	t.Run("Replies of the wrong type are reported", func(t *testing.T) {
+		t.Parallel()
+		result := performCall(t, func(r actors.Runtime) (any, error) {
+			target := r.Spawn(&serviceActor{})
+			return actors.Ask[int](r.Context(), r, target, func(replyTo actors.ReplyTo) any {
+				return askWrongType{replyTo: replyTo}
+			})
+		})
+		var wrong *actors.WrongReplyError
+		if assert.ErrorAs(t, result.err, &wrong) {
+			assert.Equal(t, "not a number", wrong.Reply)
+		}
+	})
+}
+
+type askValue struct {
+	replyTo actors.ReplyTo
+}
+
+type asyncAsker struct {
+	report  actors.Pid
+	pending bool
+}
+
+func (a *asyncAsker) OnMessage(r actors.Runtime, m any) {
+	switch m.(type) {
+	case *actors.Start:
+		target := r.Spawn(&serviceActor{state: 4})
+		ctx, done := context.WithTimeout(r.Context(), time.Second)
+		a.pending = true
+		promise := actors.AskAsync[int](ctx, r, target, func(replyTo actors.ReplyTo) any {
+			return askValue{replyTo: replyTo}
+		})
+		promise.OnCompleted(ctx, func(ctx context.Context, event task.Result[int]) {
+			done()
+			a.pending = false
+			r.Tell(a.report, event.Output)
+		})
+		r.Tell(a.report, "asked")
+	case string:
+		r.Tell(a.report, a.pending)
+	}
+}
+
+func TestAskAsync(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	sys := NewSystem()
+	port := sys.NewPort()
+	asker := sys.Spawn(ctx, &asyncAsker{report: port.Pid()})
+
+	asked, err := port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "asked", asked, "actor is not blocked while asking")
+
+	value, err := port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, value)
+
+	sys.Tell(ctx, asker, "pending?")
+	pending, err := port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, false, pending)
+}
@@ -8,11 +8,14 @@ import (
 	"runtime/debug"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/meschbach/go-junk-bucket/pkg/actors"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -31,53 +34,137 @@ type runtime struct {
 	changes    sync.Mutex
 	system     *system
 	self       actors.Pid
-	mailbox    chan tracedDecorator
+	mailbox    *mailbox
 	consumer   actors.MessageActor
 	monitoring []startMonitoring
 	state      runtimeState
 	names      map[string]actors.Pid
 	parent     *runtime
+	links      map[actors.Pid]struct{}
+	trapExits  bool
+	timers     map[*timer]struct{}
+	behaviors  []actors.MessageActor
+	//stash is guarded by changes as it is discarded when the actor is done
+	stash         []mailboxEntry
+	stashCapacity int
+	//children are the living actors spawned by this actor, guarded by changes
+	children map[*runtime]struct{}
+	//finished is closed once the actor is done
+	finished chan struct{}
+	//path caches the named path, resolved again once any names change
+	path atomic.Pointer[cachedPath]
+}
+
+// cachedPath is a named path along with the naming generation it was resolved at.
+type cachedPath struct {
+	path   string
+	naming uint64
 }
 
 func (r *runtime) told(from context.Context, m any) {
-	r.submit(from, &userMessage{m: m})
+	r.enqueue(from, &userMessage{m: m}, true)
 }
 
 func (r *runtime) start() {
 	r.changes.Lock()
-	defer r.changes.Unlock()
-
 	r.state = runtimeStarting
-	go r.run()
+	r.changes.Unlock()
+
+	r.system.scheduler.start(r)
 }
 
 func (r *runtime) done() {
 	r.changes.Lock()
-	defer r.changes.Unlock()
-
 	if r.state == runtimeDone {
+		r.changes.Unlock()
 		return
 	}
 	r.state = runtimeDone
+	r.cancelTimers()
+	stashed := r.stash
+	r.stash = nil
 	r.system.removeTarget(r.self)
-	close(r.mailbox)
-	r.mailbox = nil
+	discarded := append(stashed, r.mailbox.close()...)
+	close(r.finished)
+	r.changes.Unlock()
+
+	if r.parent != nil {
+		r.parent.removeChild(r)
+	}
+	r.undeliverable(context.Background(), discarded)
 }
 
+// undeliverable handles messages which were queued when the actor stopped.  Monitors requested in the interim are
+// informed the actor no longer exists, otherwise the watchers would wait forever.
+func (r *runtime) undeliverable(ctx context.Context, discarded []mailboxEntry) {
+	messages := make([]tracedDecorator, len(discarded))
+	for index, entry := range discarded {
+		messages[index] = entry.message
+	}
+	r.dropped(ctx, messages, actors.DeadLetterTargetDone)
+	for _, message := range messages {
+		r.unreachable(ctx, message.next)
+	}
+}
+
+func (r *runtime) unreachable(ctx context.Context, action runtimeMessage) {
+	r.system.unreachable(ctx, r.self, action)
+}
+
+// submit delivers a runtime signal to the actor behind any queued messages.  Signals are not subject to the capacity
+// of the mailbox.
 func (r *runtime) submit(from context.Context, action runtimeMessage) {
-	r.changes.Lock()
-	defer r.changes.Unlock()
+	r.enqueue(from, action, false)
+}
 
+// signal delivers a system signal to the actor ahead of any queued messages.  Senders block while the system lane is
+// full, except the actor itself which would otherwise never drain the lane and detached senders which may never block.
+func (r *runtime) signal(from context.Context, action runtimeMessage) {
 	span := trace.SpanFromContext(from)
-	//TODO: crud optimistic locking...race conditions can occur
-	switch r.state {
-	case runtimeDone:
+	span.AddEvent("submit-signal", trace.WithAttributes(attribute.Stringer("telling", r.self), attribute.String("action", action.name())))
+	block := senderBlocker(from)
+	if senderFrom(from) == r.self {
+		block = nil
+	}
+	outcome, discarded := r.mailbox.pushSystem(traceDecorator(from, action), block)
+	r.enqueued(from, span, action, outcome, discarded)
+}
+
+// enqueue places the action within the mailbox.  Bounded actions are subject to the overflow policy of the mailbox,
+// which may block or panic the sender.  Only actors and ports are panicked, otherwise the failure would crash a
+// goroutine of the runtime, so messages from elsewhere are dead lettered instead.  Detached senders are never blocked.
+// The runtime lock is not held while enqueueing so a full mailbox only stalls the sender.
+func (r *runtime) enqueue(from context.Context, action runtimeMessage, bounded bool) {
+	span := trace.SpanFromContext(from)
+	span.AddEvent("submit-signal", trace.WithAttributes(attribute.Stringer("telling", r.self), attribute.String("action", action.name())))
+	//todo: tracing layer probably should be optional
+	outcome, discarded := r.mailbox.push(traceDecorator(from, action), bounded, senderBlocker(from))
+	r.enqueued(from, span, action, outcome, discarded)
+}
+
+// enqueued reacts to the outcome of placing the action within the mailbox.
+func (r *runtime) enqueued(from context.Context, span trace.Span, action runtimeMessage, outcome enqueueOutcome, discarded []tracedDecorator) {
+	switch outcome {
+	case enqueueClosed:
 		//todo: should really just log a warning with the invoking actor
 		span.AddEvent("submit-to-done", trace.WithAttributes(attribute.Stringer("telling", r.self), attribute.String("action", fmt.Sprintf("%#v", action))))
-	default:
-		span.AddEvent("submit-signal", trace.WithAttributes(attribute.Stringer("telling", r.self), attribute.String("action", action.name())))
-		//todo: tracing layer probably should be optional
-		r.mailbox <- traceDecorator(from, action)
+		r.dropped(from, discarded, actors.DeadLetterTargetDone)
+		r.unreachable(from, action)
+	case enqueueDropped:
+		r.system.drops.record(r.mailbox.policy)
+		span.AddEvent("mailbox-overflow", trace.WithAttributes(attribute.Stringer("telling", r.self), attribute.Stringer("policy", r.mailbox.policy)))
+		r.dropped(from, discarded, actors.DeadLetterMailboxOverflow)
+	case enqueueRejected:
+		r.system.drops.record(r.mailbox.policy)
+		if senderFrom(from) == (actors.Pid{}) {
+			r.dropped(from, discarded, actors.DeadLetterMailboxFull)
+			return
+		}
+		r.dropped(from, discarded, actors.DeadLetterMailboxOverflow)
+		panic(&actors.MailboxFullError{Target: r.self, Capacity: r.mailbox.capacity})
+	case enqueueFull:
+		r.system.drops.record(r.mailbox.policy)
+		r.dropped(from, discarded, actors.DeadLetterMailboxFull)
 	}
 }
 
@@ -87,12 +174,30 @@ func (r *runtime) run() {
 	}()
 
 	r.startRunning()
-	for m := range r.mailbox {
-		if !r.isRunning() {
+	for {
+		m, ok := r.mailbox.pop()
+		if !ok || !r.isRunning() {
+			break
+		}
+		r.tick(m)
+	}
+}
+
+// activate handles up to throughput messages on behalf of a pooled scheduler, returning true if messages remain and
+// the actor must be rescheduled.
+func (r *runtime) activate(throughput int) bool {
+	for handled := 0; handled < throughput; handled++ {
+		m, ok := r.mailbox.poll()
+		if !ok || !r.isRunning() {
 			break
 		}
 		r.tick(m)
 	}
+	if !r.isRunning() {
+		r.done()
+		return false
+	}
+	return r.mailbox.yield()
 }
 
 func (r *runtime) startRunning() {
@@ -113,12 +218,19 @@ var tracer = otel.Tracer(TracerName)
 func (r *runtime) tick(signal tracedDecorator) {
 	tickBase, tickBaseDone := context.WithCancel(context.Background())
 	defer tickBaseDone()
-	parentContext := signal.baseContext(tickBase)
+	parentContext := withSender(context.WithValue(signal.baseContext(tickBase), originKey, signal.sender), r.self)
+	if pool, pooled := r.system.scheduler.(*pooledScheduler); pooled {
+		state := &tickState{pool: pool}
+		defer state.over.Store(true)
+		parentContext = context.WithValue(parentContext, tickKey, state)
+	}
 	tickContext, span := tracer.Start(parentContext, signal.next.name(), trace.WithSpanKind(trace.SpanKindConsumer))
 	defer span.End()
 	span.SetAttributes(attribute.Stringer("pid", r.self), attribute.String("name", signal.name()))
+	started := time.Now()
 
 	defer func() {
+		r.tickMetrics(tickContext, signal.next, started)
 		problem := recover()
 		if problem != nil {
 			if err, ok := problem.(error); ok {
@@ -135,15 +247,12 @@ func (r *runtime) tick(signal tracedDecorator) {
 			span.SetAttributes(attribute.StringSlice("name", nameParts))
 			name := "/" + strings.Join(nameParts, "/")
 
-			logger := r.system.loggingStrategy.buildLogger(tickContext, r.self)
+			logger := r.system.loggingStrategy.buildLogger(tickContext, r.self, r.namedPath)
 			stackTrace := debug.Stack()
 			logger.Error("actor panic: %s -- %#v\n%s", name, problem, stackTrace)
 
-			//notify listeners
-			for _, l := range r.monitoring {
-				r.system.Tell(tickContext, l.listener, actors.NewPanicExit(r.self, l.what))
-			}
-			r.done()
+			r.system.metrics.panics.Add(tickContext, 1, metric.WithAttributes(PathKey.String(name)))
+			r.exitAbnormally(tickContext, problem)
 		}
 	}()
 
@@ -151,8 +260,8 @@ func (r *runtime) tick(signal tracedDecorator) {
 }
 
 func (r *runtime) onActorExit(tickContext context.Context, result any) {
+	r.system.metrics.exits.Add(tickContext, 1, metric.WithAttributes(PathKey.String(r.namedPath())))
 	r.done()
-	r.state = runtimeDone
 	for _, l := range r.monitoring {
 		r.system.Tell(tickContext, l.listener, actors.NormalExit{
 			Who:       r.self,
@@ -160,6 +269,7 @@ func (r *runtime) onActorExit(tickContext context.Context, result any) {
 			Momento:   l.what,
 		})
 	}
+	r.notifyLinks(tickContext, result, false)
 }
 
 func (r *runtime) namedParts() []string {
@@ -170,8 +280,21 @@ func (r *runtime) namedParts() []string {
 	}
 }
 
+// namedPath is the absolute name of the actor as resolved by System.Lookup.
+func (r *runtime) namedPath() string {
+	naming := r.system.naming.Load()
+	if cached := r.path.Load(); cached != nil && cached.naming == naming {
+		return cached.path
+	}
+	path := "/" + strings.Join(r.namedParts(), "/")
+	r.path.Store(&cachedPath{path: path, naming: naming})
+	return path
+}
+
 // findForName locates the name for p within this actor.  If no name may be found "<annoymous>" is returned.
 func (r *runtime) findNameFor(p actors.Pid) string {
+	r.changes.Lock()
+	defer r.changes.Unlock()
 	for name, pid := range r.names {
 		if pid == p {
 			return name
@@ -194,7 +317,7 @@ func (u *userMessage) execute(ctx context.Context, r *runtime) {
 	span := trace.SpanFromContext(ctx)
 	span.SetName("message: " + reflect.TypeOf(u.m).String())
 	span.SetAttributes(attribute.String("pid", r.self.String()))
-	r.consumer.OnMessage(c, u.m)
+	r.handler().OnMessage(c, u.m)
 }
 
 func (u *userMessage) name() string {
@@ -205,6 +328,8 @@ func (u *userMessage) name() string {
 type tracedDecorator struct {
 	carrier map[string]string
 	next    runtimeMessage
+	//sender is the actor or port which produced the message, if known
+	sender actors.Pid
 }
 
 func (t *tracedDecorator) Get(key string) string {
@@ -241,7 +366,7 @@ func (t *tracedDecorator) name() string {
 }
 
 func traceDecorator(ctx context.Context, msg runtimeMessage) tracedDecorator {
-	decorator := tracedDecorator{carrier: make(map[string]string), next: msg}
+	decorator := tracedDecorator{carrier: make(map[string]string), next: msg, sender: senderFrom(ctx)}
 	otel.GetTextMapPropagator().Inject(ctx, &decorator)
 	return decorator
 }
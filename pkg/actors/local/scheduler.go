@@ -0,0 +1,175 @@
+package local
+
+import (
+	"context"
+	goruntime "runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultThroughput is the number of messages an actor handles before yielding its worker under a pooled scheduler.
+const DefaultThroughput = 10
+
+// scheduler decides where actors run.
+type scheduler interface {
+	//start runs the actor, which has messages waiting within its mailbox
+	start(r *runtime)
+	//stop releases the resources of the scheduler once the system has shut down
+	stop()
+}
+
+// goroutineScheduler runs each actor within its own goroutine, blocking while the mailbox is empty.
+type goroutineScheduler struct{}
+
+func (g goroutineScheduler) start(r *runtime) {
+	go r.run()
+}
+
+func (g goroutineScheduler) stop() {}
+
+// PooledSchedulerOpt runs actors on a fixed number of worker goroutines instead of a goroutine per actor, suiting
+// systems with many mostly idle actors.  An actor is only ever run by one worker at a time.  While a handler blocks
+// within actors.Call, actors.Ask, LookupPath or telling a full mailbox using OverflowBlock a spare worker is started,
+// retiring once the handler resumes, so blocked handlers never starve the other actors.  Other blocking, such as
+// sleeping or waiting on a channel, holds the worker until the handler returns.  The workers are stopped once the
+// system is shutdown.
+type PooledSchedulerOpt struct {
+	//Workers is the number of goroutines running actors, defaulting to GOMAXPROCS
+	Workers int
+	//Throughput is the maximum number of messages an actor handles before yielding its worker to other actors,
+	//defaulting to DefaultThroughput
+	Throughput int
+}
+
+func (p PooledSchedulerOpt) customizeSystem(s *system) {
+	workers := p.Workers
+	if workers <= 0 {
+		workers = goruntime.GOMAXPROCS(0)
+	}
+	throughput := p.Throughput
+	if throughput <= 0 {
+		throughput = DefaultThroughput
+	}
+	s.scheduler.stop()
+	s.scheduler = newPooledScheduler(workers, throughput)
+}
+
+// pooledScheduler runs actors with waiting messages on a fixed set of workers in the order they became ready.
+type pooledScheduler struct {
+	throughput int
+	//workers is the number of workers running actors when no handler is blocked
+	workers int
+	lock    sync.Mutex
+	ready   sync.Cond
+	queue   []*runtime
+	stopped bool
+	//live is the number of worker goroutines, exceeding workers by the spares started for blocked handlers
+	live int
+	//blocked is the number of workers whose handler is blocked
+	blocked int
+}
+
+func newPooledScheduler(workers int, throughput int) *pooledScheduler {
+	p := &pooledScheduler{throughput: throughput, workers: workers, live: workers}
+	p.ready.L = &p.lock
+	for range workers {
+		go p.work()
+	}
+	return p
+}
+
+// tickState identifies a tick run by a pooled scheduler, allowing blocking operations within the handler to compensate.
+type tickState struct {
+	pool *pooledScheduler
+	//over is set once the tick has completed, as contexts derived from the tick may outlive it
+	over atomic.Bool
+}
+
+// blocking informs the pooled scheduler running the tick of ctx, if any, that the handler is about to block.  The
+// returned function must be invoked once the handler resumes.
+func blocking(ctx context.Context) func() {
+	if state, ok := ctx.Value(tickKey).(*tickState); ok && !state.over.Load() {
+		return state.pool.blocking()
+	}
+	return func() {}
+}
+
+// blocking starts a spare worker when the blocked handler would leave fewer than the configured number of workers
+// running actors.  Spares retire once they find more workers running than configured.
+func (p *pooledScheduler) blocking() func() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.blocked++
+	if !p.stopped && p.live-p.blocked < p.workers {
+		p.live++
+		go p.work()
+	}
+	return p.resumed
+}
+
+func (p *pooledScheduler) resumed() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.blocked--
+	p.ready.Signal()
+}
+
+func (p *pooledScheduler) start(r *runtime) {
+	r.startRunning()
+	r.mailbox.attach(func() {
+		p.schedule(r)
+	})
+}
+
+// schedule queues the actor to be run by the next available worker.  Never blocks as it is invoked while the mailbox
+// of the actor is locked.
+func (p *pooledScheduler) schedule(r *runtime) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.stopped {
+		return
+	}
+	p.queue = append(p.queue, r)
+	p.ready.Signal()
+}
+
+// next blocks until an actor is ready, returning false once the scheduler has stopped or the worker is a spare which
+// is no longer needed.
+func (p *pooledScheduler) next() (*runtime, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for len(p.queue) == 0 && !p.stopped && p.live-p.blocked <= p.workers {
+		p.ready.Wait()
+	}
+	if p.stopped {
+		return nil, false
+	}
+	if p.live-p.blocked > p.workers {
+		p.live--
+		return nil, false
+	}
+	r := p.queue[0]
+	p.queue[0] = nil
+	p.queue = p.queue[1:]
+	return r, true
+}
+
+func (p *pooledScheduler) work() {
+	for {
+		r, ok := p.next()
+		if !ok {
+			return
+		}
+		if r.activate(p.throughput) {
+			p.schedule(r)
+		}
+	}
+}
+
+func (p *pooledScheduler) stop() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.stopped = true
+	p.queue = nil
+	p.ready.Broadcast()
+}
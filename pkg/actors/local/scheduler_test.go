@@ -0,0 +1,388 @@
+package local
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echo tells the port within each message the name of the actor and the message.
+type echo struct {
+	name string
+}
+
+type echoed struct {
+	name  string
+	value int
+}
+
+type echoRequest struct {
+	reply actors.Pid
+	value int
+}
+
+func (e *echo) OnMessage(r actors.Runtime, m any) {
+	if request, ok := m.(echoRequest); ok {
+		r.Tell(request.reply, echoed{name: e.name, value: request.value})
+	}
+}
+
+// exclusive records whether it was ever run by more than one worker at a time.
+type exclusive struct {
+	running    atomic.Int32
+	overlapped atomic.Bool
+	handled    int
+}
+
+func (e *exclusive) OnMessage(r actors.Runtime, m any) {
+	if e.running.Add(1) > 1 {
+		e.overlapped.Store(true)
+	}
+	defer e.running.Add(-1)
+	if reply, ok := m.(actors.Pid); ok {
+		e.handled++
+		r.Tell(reply, e.handled)
+	}
+}
+
+func TestPooledScheduler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("runs many actors on few workers", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem(PooledSchedulerOpt{Workers: 2})
+		port := sys.NewPort()
+		const count = 1000
+		for i := range count {
+			sys.Tell(ctx, sys.Spawn(ctx, &echo{}), echoRequest{reply: port.Pid(), value: i})
+		}
+		seen := make(map[int]bool)
+		for range count {
+			reply, err := port.ReceiveWith(ctx)
+			require.NoError(t, err)
+			seen[reply.(echoed).value] = true
+		}
+		assert.Len(t, seen, count)
+		require.NoError(t, sys.Shutdown(ctx))
+	})
+
+	t.Run("actors run on one worker at a time", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem(PooledSchedulerOpt{Workers: 8, Throughput: 1})
+		port := sys.NewPort()
+		actor := &exclusive{}
+		pid := sys.Spawn(ctx, actor, actors.MailboxOpt{Overflow: actors.OverflowUnbounded})
+		const senders, messages = 8, 100
+		for range senders {
+			go func() {
+				for range messages {
+					sys.Tell(context.Background(), pid, port.Pid())
+				}
+			}()
+		}
+		var last any
+		for range senders * messages {
+			var err error
+			last, err = port.ReceiveWith(ctx)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, senders*messages, last)
+		assert.False(t, actor.overlapped.Load())
+	})
+
+	t.Run("throughput limits each activation", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem(PooledSchedulerOpt{Workers: 1, Throughput: 1})
+		port := sys.NewPort()
+		first := sys.Spawn(ctx, &echo{name: "first"})
+		second := sys.Spawn(ctx, &echo{name: "second"})
+
+		gated := &gatedActor{gate: make(chan struct{}), release: make(chan struct{})}
+		gate := gated.gate
+		sys.Tell(ctx, sys.Spawn(ctx, gated), 0)
+		<-gate
+		for i := range 3 {
+			sys.Tell(ctx, first, echoRequest{reply: port.Pid(), value: i})
+		}
+		for i := range 3 {
+			sys.Tell(ctx, second, echoRequest{reply: port.Pid(), value: i})
+		}
+		close(gated.release)
+
+		var order []echoed
+		for range 6 {
+			reply, err := port.ReceiveWith(ctx)
+			require.NoError(t, err)
+			order = append(order, reply.(echoed))
+		}
+		assert.Equal(t, []echoed{
+			{name: "first", value: 0}, {name: "second", value: 0},
+			{name: "first", value: 1}, {name: "second", value: 1},
+			{name: "first", value: 2}, {name: "second", value: 2},
+		}, order)
+	})
+
+	t.Run("shutdown stops actors", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem(PooledSchedulerOpt{Workers: 1})
+		port := sys.NewPort()
+		pid := sys.Spawn(ctx, &echo{}, actors.MonitorOpt{Tell: port.Pid()})
+		require.NoError(t, sys.Shutdown(ctx))
+		exit, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, actors.NormalExit{Who: pid, ExitValue: actors.Stopping{}}, exit)
+	})
+}
+
+// flooder tells each value to the target on start, reporting once all have been accepted.
+type flooder struct {
+	target actors.Pid
+	values int
+	report actors.Pid
+}
+
+func (f *flooder) OnMessage(r actors.Runtime, m any) {
+	if _, ok := m.(*actors.Start); ok {
+		for i := range f.values {
+			r.Tell(f.target, i)
+		}
+		r.Tell(f.report, "flooded")
+	}
+}
+
+// relay tells the port each value received.
+type relay struct {
+	report actors.Pid
+}
+
+func (r *relay) OnMessage(bif actors.Runtime, m any) {
+	if value, ok := m.(int); ok {
+		bif.Tell(r.report, value)
+	}
+}
+
+// forwardingAsker answers each ask by calling the service, blocking its handler until the service replies.
+type forwardingAsker struct {
+	service actors.Pid
+}
+
+func (f *forwardingAsker) OnMessage(r actors.Runtime, m any) {
+	if msg, ok := m.(askValue); ok {
+		value, err := actors.Call[int, int](r.Context(), r, f.service, &doubleAction{})
+		if err != nil {
+			panic(err)
+		}
+		msg.replyTo.Reply(r, value)
+	}
+}
+
+func TestPooledSchedulerBlocking(t *testing.T) {
+	t.Parallel()
+
+	performPooled := func(t *testing.T, perform func(r actors.Runtime) (any, error)) callResult {
+		ctx := t.Context()
+		sys := NewSystem(PooledSchedulerOpt{Workers: 1})
+		port := sys.NewPort()
+		sys.Spawn(ctx, &caller{perform: perform, report: port.Pid()})
+		result, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		require.NoError(t, sys.Shutdown(ctx))
+		return result.(callResult)
+	}
+
+	t.Run("Calls from handlers are answered", func(t *testing.T) {
+		t.Parallel()
+		result := performPooled(t, func(r actors.Runtime) (any, error) {
+			target := r.Spawn(&serviceActor{state: 21})
+			ctx, done := context.WithTimeout(r.Context(), time.Second)
+			defer done()
+			return actors.Call[int, int](ctx, r, target, &doubleAction{})
+		})
+		require.NoError(t, result.err)
+		assert.Equal(t, 42, result.value)
+	})
+
+	t.Run("Asks from handlers are answered", func(t *testing.T) {
+		t.Parallel()
+		result := performPooled(t, func(r actors.Runtime) (any, error) {
+			target := r.Spawn(&serviceActor{state: 4})
+			ctx, done := context.WithTimeout(r.Context(), time.Second)
+			defer done()
+			return actors.Ask[int](ctx, r, target, func(replyTo actors.ReplyTo) any {
+				return askValue{replyTo: replyTo}
+			})
+		})
+		require.NoError(t, result.err)
+		assert.Equal(t, 4, result.value)
+	})
+
+	t.Run("Nested calls are answered", func(t *testing.T) {
+		t.Parallel()
+		result := performPooled(t, func(r actors.Runtime) (any, error) {
+			service := r.Spawn(&serviceActor{state: 3})
+			middle := r.Spawn(&forwardingAsker{service: service})
+			ctx, done := context.WithTimeout(r.Context(), time.Second)
+			defer done()
+			return actors.Ask[int](ctx, r, middle, func(replyTo actors.ReplyTo) any {
+				return askValue{replyTo: replyTo}
+			})
+		})
+		require.NoError(t, result.err)
+		assert.Equal(t, 6, result.value)
+	})
+
+	t.Run("Senders blocked on a full mailbox do not stall the pool", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem(PooledSchedulerOpt{Workers: 1})
+		port := sys.NewPort()
+		target := sys.Spawn(ctx, &relay{report: port.Pid()}, actors.MailboxOpt{Capacity: 1, Overflow: actors.OverflowBlock})
+		const values = 8
+		sys.Spawn(ctx, &flooder{target: target, values: values, report: port.Pid()})
+
+		var received []int
+		flooded := false
+		for len(received) < values || !flooded {
+			reply, err := port.ReceiveWith(ctx)
+			require.NoError(t, err)
+			switch msg := reply.(type) {
+			case int:
+				received = append(received, msg)
+			case string:
+				flooded = true
+			}
+		}
+		assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7}, received)
+		require.NoError(t, sys.Shutdown(ctx))
+	})
+
+	t.Run("Spare workers retire once handlers resume", func(t *testing.T) {
+		t.Parallel()
+		p := newPooledScheduler(1, 1)
+		defer p.stop()
+		resume := p.blocking()
+		p.lock.Lock()
+		assert.Equal(t, 2, p.live, "a spare is started while the handler blocks")
+		p.lock.Unlock()
+		resume()
+		assert.Eventually(t, func() bool {
+			p.lock.Lock()
+			defer p.lock.Unlock()
+			return p.live == 1
+		}, time.Second, time.Millisecond)
+	})
+}
+
+// schedulerModes are the ways a system may run actors, compared by the benchmarks.
+var schedulerModes = []struct {
+	name string
+	opts []SystemOpts
+}{
+	{name: "goroutine"},
+	{name: "pooled", opts: []SystemOpts{PooledSchedulerOpt{}}},
+}
+
+func newBenchmarkSystem(b *testing.B, opts []SystemOpts) actors.System {
+	sys := NewSystem(opts...)
+	b.Cleanup(func() {
+		sys.Shutdown(context.Background()) //nolint
+	})
+	return sys
+}
+
+// rally bounces each message back to the sender until the count reaches zero, then tells done.
+type rally struct {
+	done actors.Pid
+}
+
+type volley struct {
+	from      actors.Pid
+	remaining int
+}
+
+func (r *rally) OnMessage(bif actors.Runtime, m any) {
+	if v, ok := m.(volley); ok {
+		if v.remaining == 0 {
+			bif.Tell(r.done, v)
+			return
+		}
+		bif.Tell(v.from, volley{from: bif.Self(), remaining: v.remaining - 1})
+	}
+}
+
+// BenchmarkSchedulerPingPong measures the latency of messages exchanged between two actors.
+func BenchmarkSchedulerPingPong(b *testing.B) {
+	for _, mode := range schedulerModes {
+		b.Run(mode.name, func(b *testing.B) {
+			ctx := b.Context()
+			sys := newBenchmarkSystem(b, mode.opts)
+			port := sys.NewPort()
+			ping := sys.Spawn(ctx, &rally{done: port.Pid()})
+			pong := sys.Spawn(ctx, &rally{done: port.Pid()})
+			b.ReportAllocs()
+			b.ResetTimer()
+			sys.Tell(ctx, ping, volley{from: pong, remaining: b.N})
+			if _, err := port.ReceiveWith(ctx); err != nil {
+				b.Fatal(err)
+			}
+		})
+	}
+}
+
+// BenchmarkSchedulerFanOut measures a round of messages to many idle actors, each replying once.
+func BenchmarkSchedulerFanOut(b *testing.B) {
+	for _, actorCount := range []int{1000, 100000} {
+		for _, mode := range schedulerModes {
+			b.Run(mode.name+"/Actors="+strconv.Itoa(actorCount), func(b *testing.B) {
+				ctx := b.Context()
+				sys := newBenchmarkSystem(b, mode.opts)
+				port := sys.NewPort()
+				pids := make([]actors.Pid, actorCount)
+				for index := range pids {
+					pids[index] = sys.Spawn(ctx, &echo{})
+				}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for b.Loop() {
+					for _, pid := range pids {
+						sys.Tell(ctx, pid, echoRequest{reply: port.Pid()})
+					}
+					for range pids {
+						if _, err := port.ReceiveWith(ctx); err != nil {
+							b.Fatal(err)
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkSchedulerSpawn measures spawning an actor and exchanging a message with it.
+func BenchmarkSchedulerSpawn(b *testing.B) {
+	for _, mode := range schedulerModes {
+		b.Run(mode.name, func(b *testing.B) {
+			ctx := b.Context()
+			sys := newBenchmarkSystem(b, mode.opts)
+			port := sys.NewPort()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for b.Loop() {
+				sys.Tell(ctx, sys.Spawn(ctx, &echo{}), echoRequest{reply: port.Pid()})
+				if _, err := port.ReceiveWith(ctx); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
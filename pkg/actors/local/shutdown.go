@@ -0,0 +1,121 @@
+package local
+
+import (
+	"context"
+	"sync"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// stopSignal delivers Stopping to the actor then exits normally.  Submitted behind any queued messages so the mailbox
+// drains first.
+type stopSignal struct{}
+
+func (s *stopSignal) execute(ctx context.Context, r *runtime) {
+	stopping := &userMessage{m: actors.Stopping{}}
+	stopping.execute(ctx, r)
+	r.onActorExit(ctx, actors.Stopping{})
+}
+
+func (s *stopSignal) name() string {
+	return "stopping"
+}
+
+func (r *runtime) childRuntimes() []*runtime {
+	r.changes.Lock()
+	defer r.changes.Unlock()
+	out := make([]*runtime, 0, len(r.children))
+	for child := range r.children {
+		out = append(out, child)
+	}
+	return out
+}
+
+func (r *runtime) isFinished() bool {
+	select {
+	case <-r.finished:
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *runtime) removeChild(child *runtime) {
+	r.changes.Lock()
+	defer r.changes.Unlock()
+	delete(r.children, child)
+}
+
+// stopTree stops the children of r before stopping r, waiting until each has finished or ctx is done.
+func (s *system) stopTree(ctx context.Context, r *runtime) {
+	s.stopAll(ctx, r.childRuntimes())
+	if ctx.Err() != nil {
+		return
+	}
+	r.submit(ctx, &stopSignal{})
+	select {
+	case <-r.finished:
+	case <-ctx.Done():
+	}
+}
+
+func (s *system) stopAll(ctx context.Context, runtimes []*runtime) {
+	var wg sync.WaitGroup
+	for _, r := range runtimes {
+		wg.Go(func() {
+			s.stopTree(ctx, r)
+		})
+	}
+	wg.Wait()
+}
+
+func (s *system) runtimes() []*runtime {
+	s.actorLock.RLock()
+	defer s.actorLock.RUnlock()
+	out := make([]*runtime, 0, len(s.actors))
+	for _, target := range s.actors {
+		if r, ok := target.(*runtime); ok {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func (s *system) Shutdown(ctx context.Context) error {
+	shutdownContext, span := tracer.Start(ctx, "system.Shutdown")
+	defer span.End()
+
+	var roots []*runtime
+	for _, r := range s.runtimes() {
+		if r.parent == nil || r.parent.isFinished() {
+			roots = append(roots, r)
+		}
+	}
+	s.stopAll(shutdownContext, roots)
+
+	var remaining []actors.Pid
+	for _, r := range s.runtimes() {
+		span.AddEvent("force-close", trace.WithAttributes(attribute.Stringer("pid", r.self)))
+		remaining = append(remaining, r.self)
+		r.done()
+	}
+
+	s.linkLock.Lock()
+	links := make([]*nodeLink, 0, len(s.links))
+	for _, link := range s.links {
+		links = append(links, link)
+	}
+	s.linkLock.Unlock()
+	for _, link := range links {
+		s.dropLink(shutdownContext, link)
+	}
+	s.metrics.close()
+	s.scheduler.stop()
+
+	if len(remaining) > 0 {
+		return &actors.ShutdownError{Remaining: remaining}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package local
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stoppingReporter struct {
+	label    string
+	report   actors.Pid
+	children []string
+}
+
+func (s *stoppingReporter) OnMessage(r actors.Runtime, m any) {
+	switch m.(type) {
+	case *actors.Start:
+		for _, child := range s.children {
+			r.Spawn(&stoppingReporter{label: child, report: s.report})
+		}
+	case actors.Stopping:
+		r.Tell(s.report, s.label)
+	}
+}
+
+type stuckActor struct {
+	release chan struct{}
+}
+
+func (s *stuckActor) OnMessage(r actors.Runtime, m any) {
+	if _, ok := m.(actors.Stopping); ok {
+		<-s.release
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Children stop before parents", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		port := sys.NewPort()
+		sys.Spawn(ctx, &stoppingReporter{label: "parent", report: port.Pid(), children: []string{"child"}})
+
+		// ensure the child has been spawned
+		require.Eventually(t, func() bool {
+			return len(sys.(*system).runtimes()) == 2
+		}, time.Second, time.Millisecond)
+
+		require.NoError(t, sys.Shutdown(ctx))
+		first, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		second, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []any{"child", "parent"}, []any{first, second})
+		assert.Empty(t, sys.(*system).runtimes())
+	})
+
+	t.Run("Actors which do not stop in time are reported", func(t *testing.T) {
+		t.Parallel()
+		sys := NewSystem()
+		release := make(chan struct{})
+		t.Cleanup(func() {
+			close(release)
+		})
+		stuck := sys.Spawn(t.Context(), &stuckActor{release: release})
+
+		ctx, done := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer done()
+		err := sys.Shutdown(ctx)
+		var shutdown *actors.ShutdownError
+		if assert.ErrorAs(t, err, &shutdown) {
+			assert.Equal(t, []actors.Pid{stuck}, shutdown.Remaining)
+		}
+		assert.Empty(t, sys.(*system).runtimes())
+	})
+}
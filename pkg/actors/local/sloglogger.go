@@ -0,0 +1,87 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SlogLoggingStrategy writes records through a slog.Logger.  Each record carries the pid and named path of the actor
+// along with the trace and span of the current tick when one is recorded.
+type SlogLoggingStrategy struct {
+	//Logger receives the records, defaulting to slog.Default
+	Logger *slog.Logger
+	//Level is the minimum level recorded, in addition to any filtering by the Logger's handler
+	Level slog.Leveler
+}
+
+func (s *SlogLoggingStrategy) buildLogger(ctx context.Context, who actors.Pid, path func() string) actors.Logger {
+	logger := s.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	attrs := []any{slog.String("pid", who.String())}
+	if path != nil {
+		attrs = append(attrs, slog.Any("path", lazyPath(path)))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		attrs = append(attrs, slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return &slogLogger{ctx: ctx, logger: logger.With(attrs...), level: s.Level}
+}
+
+func (s *SlogLoggingStrategy) customizeSystem(sys *system) {
+	sys.loggingStrategy = s
+}
+
+// lazyPath resolves the named path only when a record is written.
+type lazyPath func() string
+
+func (l lazyPath) LogValue() slog.Value {
+	return slog.StringValue(l())
+}
+
+type slogLogger struct {
+	ctx    context.Context
+	logger *slog.Logger
+	level  slog.Leveler
+}
+
+func (s *slogLogger) Enabled(level slog.Level) bool {
+	if s.level != nil && level < s.level.Level() {
+		return false
+	}
+	return s.logger.Enabled(s.ctx, level)
+}
+
+func (s *slogLogger) Log(level slog.Level, msg string, args ...any) {
+	if !s.Enabled(level) {
+		return
+	}
+	s.logger.Log(s.ctx, level, msg, args...)
+}
+
+func (s *slogLogger) With(args ...any) actors.StructuredLogger {
+	return &slogLogger{ctx: s.ctx, logger: s.logger.With(args...), level: s.level}
+}
+
+func (s *slogLogger) Info(format string, args ...any) {
+	s.Log(slog.LevelInfo, fmt.Sprintf(format, args...))
+}
+
+func (s *slogLogger) Warn(format string, args ...any) {
+	s.Log(slog.LevelWarn, fmt.Sprintf(format, args...))
+}
+
+func (s *slogLogger) Error(format string, args ...any) {
+	s.Log(slog.LevelError, fmt.Sprintf(format, args...))
+}
+
+func (s *slogLogger) Fatal(format string, args ...any) {
+	problem := fmt.Sprintf(format, args...)
+	s.Log(slog.LevelError, problem, slog.Bool("fatal", true))
+	panic(problem)
+}
@@ -0,0 +1,133 @@
+package local
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// lockedBuffer collects output written from actor goroutines.
+type lockedBuffer struct {
+	lock sync.Mutex
+	out  bytes.Buffer
+}
+
+func (l *lockedBuffer) Write(p []byte) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.out.Write(p)
+}
+
+// records decodes each JSON record written.
+func (l *lockedBuffer) records(t *testing.T) []map[string]any {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(l.out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		out = append(out, record)
+	}
+	return out
+}
+
+// structuredLogging logs upon each message then replies to the port within the message.
+type structuredLogging struct{}
+
+func (s *structuredLogging) OnMessage(r actors.Runtime, m any) {
+	if reply, ok := m.(actors.Pid); ok {
+		logger := actors.Structured(r.Log()).With("request", 7)
+		logger.Log(slog.LevelDebug, "filtered")
+		logger.Log(slog.LevelInfo, "handled", "count", 3)
+		r.Log().Warn("printf %d", 4)
+		r.Tell(reply, "done")
+	}
+}
+
+// spawnsLogging registers a structuredLogging child as "worker", telling report of the child.
+type spawnsLogging struct {
+	report actors.Pid
+}
+
+func (s *spawnsLogging) OnMessage(r actors.Runtime, m any) {
+	if _, ok := m.(*actors.Start); ok {
+		worker := r.Spawn(&structuredLogging{})
+		r.Register("worker", worker)
+		r.Tell(s.report, worker)
+	}
+}
+
+func TestSlogLoggingStrategy(t *testing.T) {
+	t.Parallel()
+	t.Run("actor identity", func(t *testing.T) {
+		t.Parallel()
+		ctx, done := context.WithCancel(context.Background())
+		t.Cleanup(done)
+		out := &lockedBuffer{}
+		sys := NewSystem(&SlogLoggingStrategy{Logger: slog.New(slog.NewJSONHandler(out, nil))})
+		port := sys.NewPort()
+		sys.Spawn(ctx, &spawnsLogging{report: port.Pid()})
+		spawned, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		worker := spawned.(actors.Pid)
+
+		sys.Tell(ctx, worker, port.Pid())
+		_, err = port.ReceiveWith(ctx)
+		require.NoError(t, err)
+
+		records := out.records(t)
+		require.Len(t, records, 2, "debug records are filtered")
+		assert.Equal(t, "handled", records[0]["msg"])
+		assert.Equal(t, "INFO", records[0]["level"])
+		assert.Equal(t, worker.String(), records[0]["pid"])
+		assert.Equal(t, "/worker", records[0]["path"])
+		assert.Equal(t, float64(7), records[0]["request"])
+		assert.Equal(t, float64(3), records[0]["count"])
+		assert.Equal(t, "printf 4", records[1]["msg"])
+		assert.Equal(t, "WARN", records[1]["level"])
+	})
+
+	t.Run("level and trace", func(t *testing.T) {
+		t.Parallel()
+		out := &lockedBuffer{}
+		strategy := &SlogLoggingStrategy{Logger: slog.New(slog.NewJSONHandler(out, nil)), Level: slog.LevelWarn}
+		traceID := trace.TraceID{1, 2, 3}
+		spanID := trace.SpanID{4, 5, 6}
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+		logger := strategy.buildLogger(ctx, actors.Pid{Process: 9}, nil)
+		logger.Info("filtered")
+		logger.Error("kept")
+		assert.Panics(t, func() {
+			logger.Fatal("fatal %d", 1)
+		})
+
+		records := out.records(t)
+		require.Len(t, records, 2)
+		assert.Equal(t, "kept", records[0]["msg"])
+		assert.Equal(t, traceID.String(), records[0]["trace_id"])
+		assert.Equal(t, spanID.String(), records[0]["span_id"])
+		assert.NotContains(t, records[0], "path")
+		assert.Equal(t, "fatal 1", records[1]["msg"])
+		assert.Equal(t, true, records[1]["fatal"])
+	})
+}
+
+func TestCompositeLoggerFatal(t *testing.T) {
+	t.Parallel()
+	logger := (&CompositeLoggingStrategy{Loggers: []LoggingStrategy{&OTELLoggingStrategy{}, &ConsoleLoggingStrategy{}}}).buildLogger(context.Background(), actors.Pid{Process: 1}, nil)
+	assert.PanicsWithValue(t, "failed 3", func() {
+		logger.Fatal("failed %d", 3)
+	})
+}
@@ -8,8 +8,10 @@ import (
 	"sync/atomic"
 
 	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/meschbach/go-junk-bucket/pkg/emitter"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -23,12 +25,26 @@ type system struct {
 	actors          map[actors.Pid]messageTarget
 	root            *runtime
 	loggingStrategy LoggingStrategy
+
+	//node is the identity of this system amongst linked systems
+	node     uint64
+	linkLock sync.Mutex
+	links    map[uint64]*nodeLink
+	payloads payloadCodec
+
+	drops         dropCounters
+	deadLetters   *emitter.MutexDispatcher[actors.DeadLetter]
+	meterProvider metric.MeterProvider
+	metrics       systemMetrics
+	scheduler     scheduler
+	//naming counts changes to the names registered by any actor, invalidating the cached named paths
+	naming atomic.Uint64
 }
 
 func (s *system) nextPID() actors.Pid {
 	id := atomic.AddUint64(&s.nextID, 1)
 	return actors.Pid{
-		Node:    0,
+		Node:    s.node,
 		Process: id,
 	}
 }
@@ -56,10 +72,15 @@ func (s *system) NewPort() actors.Port {
 
 // TODO: similar to another spot, merge?
 func (s *system) Tell(ctx context.Context, p actors.Pid, m any) {
+	if s.isRemote(p) {
+		s.tellRemote(ctx, p, m)
+		return
+	}
 	actor := s.pid2target(p)
 	if actor == nil {
 		span := trace.SpanFromContext(ctx)
 		span.AddEvent("missing-target", trace.WithAttributes(attribute.String("target", p.String())))
+		s.deadLetter(ctx, "", actors.DeadLetter{Target: p, Sender: senderFrom(ctx), Message: m, Reason: actors.DeadLetterNoTarget})
 	} else {
 		actor.told(ctx, m)
 	}
@@ -67,8 +88,11 @@ func (s *system) Tell(ctx context.Context, p actors.Pid, m any) {
 
 func (s *system) Spawn(context context.Context, a actors.MessageActor, opts ...any) actors.Pid {
 	var monitoring []actors.MonitorOpt
+	var links []actors.Pid
 	var parent *runtime = nil
 	var registerAs *string = nil
+	mailboxOpt := actors.MailboxOpt{Capacity: defaultMailboxCapacity, Overflow: actors.OverflowBlock}
+	stashCapacity := defaultStashCapacity
 	for _, opt := range opts {
 		switch o := opt.(type) {
 		case actors.MonitorOpt:
@@ -79,6 +103,12 @@ func (s *system) Spawn(context context.Context, a actors.MessageActor, opts ...a
 			parent = o.who
 		case actors.RegisterOpt:
 			registerAs = &o.Name
+		case actors.MailboxOpt:
+			mailboxOpt = o
+		case actors.LinkOpt:
+			links = append(links, o.With)
+		case actors.StashOpt:
+			stashCapacity = o.Capacity
 		default:
 			panic(fmt.Sprintf("unknown option type %#v", opt))
 		}
@@ -86,29 +116,48 @@ func (s *system) Spawn(context context.Context, a actors.MessageActor, opts ...a
 
 	pid := s.nextPID()
 	r := &runtime{
-		changes:  sync.Mutex{},
-		system:   s,
-		self:     pid,
-		mailbox:  make(chan tracedDecorator, 16),
-		consumer: a,
-		state:    runtimeInit,
-		names:    make(map[string]actors.Pid),
-		parent:   parent,
+		changes:       sync.Mutex{},
+		system:        s,
+		self:          pid,
+		mailbox:       newMailbox(mailboxOpt),
+		consumer:      a,
+		state:         runtimeInit,
+		names:         make(map[string]actors.Pid),
+		parent:        parent,
+		links:         make(map[actors.Pid]struct{}),
+		timers:        make(map[*timer]struct{}),
+		stashCapacity: stashCapacity,
+		children:      make(map[*runtime]struct{}),
+		finished:      make(chan struct{}),
 	}
 	r.changes.Lock()
 	if s.root == nil {
 		s.root = r
 	}
-	if r.parent != nil && registerAs != nil {
-		r.parent.names[*registerAs] = pid
-	}
 	r.changes.Unlock()
+	if r.parent != nil {
+		r.parent.changes.Lock()
+		if registerAs != nil {
+			r.parent.names[*registerAs] = pid
+		}
+		r.parent.children[r] = struct{}{}
+		r.parent.changes.Unlock()
+		if registerAs != nil {
+			s.naming.Add(1)
+		}
+	}
 
 	for _, m := range monitoring {
-		r.submit(context, &startMonitoring{listener: m.Tell, what: m.Momento})
+		r.signal(context, &startMonitoring{listener: m.Tell, what: m.Momento})
 	}
-	r.told(context, &actors.Start{})
+	//Start is delivered through the system lane so it precedes signals, such as lookups, sent once Spawn returns
+	r.signal(context, &userMessage{m: &actors.Start{}})
 	s.registerTarget(pid, r)
+	//links are requested once registered so partners which are gone may answer with NoProcess
+	for _, with := range links {
+		r.links[with] = struct{}{}
+		s.execute(context, with, &linkSignal{with: pid})
+	}
 	r.start()
 	return pid
 }
@@ -117,11 +166,17 @@ func NewSystem(opts ...SystemOpts) actors.System {
 	out := &system{
 		nextID:          0,
 		actors:          make(map[actors.Pid]messageTarget),
-		loggingStrategy: &CompositeLoggingStrategy{Loggers: []LoggingStrategy{&ConsoleLoggingStrategy{}, &CompositeLoggingStrategy{}}},
+		links:           make(map[uint64]*nodeLink),
+		payloads:        gobPayloads{},
+		deadLetters:     emitter.NewMutexDispatcher[actors.DeadLetter](),
+		meterProvider:   otel.GetMeterProvider(),
+		scheduler:       goroutineScheduler{},
+		loggingStrategy: &CompositeLoggingStrategy{Loggers: []LoggingStrategy{&ConsoleLoggingStrategy{}, &OTELLoggingStrategy{}}},
 	}
 	for _, opt := range opts {
 		opt.customizeSystem(out)
 	}
+	out.metrics = newSystemMetrics(out)
 	return out
 }
 
@@ -136,12 +191,28 @@ func (s *system) execute(from context.Context, targetPID actors.Pid, action runt
 	//TODO: if target has exited this will generate a panic
 	// - can be triggered by attempting to grant to a nonexistent user
 	// - implemented a type test but not really the best choice
+	if s.isRemote(targetPID) {
+		s.executeRemote(from, targetPID, action)
+		return
+	}
 	rawTarget := s.pid2target(targetPID)
 	if target, ok := rawTarget.(*runtime); ok {
-		target.submit(from, action)
+		target.signal(from, action)
 	} else {
 		span := trace.SpanFromContext(from)
 		span.AddEvent("no-such-pid", trace.WithAttributes(attribute.Stringer("pid", targetPID)))
+		s.unreachable(from, targetPID, action)
+	}
+}
+
+// unreachable answers signals which expect a reply when the target is gone, as Erlang does with noproc.  Monitors
+// receive a normal exit and linkers an abnormal link exit, both with NoProcess as the reason.
+func (s *system) unreachable(ctx context.Context, target actors.Pid, action runtimeMessage) {
+	switch a := action.(type) {
+	case *startMonitoring:
+		s.Tell(ctx, a.listener, actors.NormalExit{Who: target, ExitValue: actors.NoProcess{}, Momento: a.what})
+	case *linkSignal:
+		s.execute(ctx, a.with, &linkExitSignal{from: target, reason: actors.NoProcess{}, abnormal: true})
 	}
 }
 
@@ -0,0 +1,129 @@
+package local
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	timerActive = iota
+	timerCancelled
+	timerDelivered
+)
+
+type timer struct {
+	owner   *runtime
+	message any
+	//period is zero for timers which deliver once
+	period time.Duration
+	//origin is the span which scheduled the timer, allowing deliveries to be traced back
+	origin trace.SpanContext
+	clock  *time.Timer
+	state  atomic.Uint32
+}
+
+func (t *timer) Cancel() bool {
+	if !t.state.CompareAndSwap(timerActive, timerCancelled) {
+		return false
+	}
+	t.clock.Stop()
+	t.owner.forgetTimer(t)
+	return true
+}
+
+// fire is invoked by the clock, queueing the delivery through the owner's mailbox.  The clock is not an actor so a
+// firing the mailbox will not accept is dead lettered rather than blocking or failing the clock.
+func (t *timer) fire() {
+	if t.state.Load() != timerActive {
+		return
+	}
+	ctx := withDetached(trace.ContextWithRemoteSpanContext(context.Background(), t.origin))
+	t.owner.enqueue(ctx, &timerFired{timer: t}, true)
+	if t.period > 0 {
+		t.clock.Reset(t.period)
+	}
+}
+
+// timerFired delivers the timer's message unless the timer was cancelled while the delivery was queued.
+type timerFired struct {
+	timer *timer
+}
+
+func (f *timerFired) execute(ctx context.Context, r *runtime) {
+	t := f.timer
+	if t.period == 0 {
+		if !t.state.CompareAndSwap(timerActive, timerDelivered) {
+			return
+		}
+		r.forgetTimer(t)
+	} else if t.state.Load() != timerActive {
+		return
+	}
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Bool("interval", t.period > 0))
+	deliver := &userMessage{m: t.message}
+	deliver.execute(ctx, r)
+}
+
+// discarded settles one shot timers whose firing was dropped, as the message will never be delivered.
+func (f *timerFired) discarded(r *runtime) {
+	t := f.timer
+	if t.period == 0 && t.state.CompareAndSwap(timerActive, timerDelivered) {
+		r.forgetTimer(t)
+	}
+}
+
+func (f *timerFired) name() string {
+	return "timer: " + reflect.TypeOf(f.timer.message).String()
+}
+
+func (r *runtime) schedule(ctx context.Context, delay time.Duration, period time.Duration, m any) *timer {
+	t := &timer{
+		owner:   r,
+		message: m,
+		period:  period,
+		origin:  trace.SpanContextFromContext(ctx),
+	}
+	r.changes.Lock()
+	defer r.changes.Unlock()
+	if r.state == runtimeDone {
+		t.state.Store(timerCancelled)
+		return t
+	}
+	r.timers[t] = struct{}{}
+	//armed after assignment so fire always observes the clock
+	t.clock = time.AfterFunc(math.MaxInt64, t.fire)
+	t.clock.Reset(delay)
+	return t
+}
+
+func (r *runtime) forgetTimer(t *timer) {
+	r.changes.Lock()
+	defer r.changes.Unlock()
+	delete(r.timers, t)
+}
+
+// cancelTimers stops all outstanding timers.  Must be called with the changes lock held.
+func (r *runtime) cancelTimers() {
+	for t := range r.timers {
+		if t.state.CompareAndSwap(timerActive, timerCancelled) {
+			t.clock.Stop()
+		}
+	}
+	r.timers = nil
+}
+
+func (c *container) SendAfter(delay time.Duration, m any) actors.TimerRef {
+	return c.r.schedule(c.tickContext, delay, 0, m)
+}
+
+func (c *container) SendInterval(period time.Duration, m any) actors.TimerRef {
+	return c.r.schedule(c.tickContext, period, period, m)
+}
@@ -0,0 +1,149 @@
+package local
+
+import (
+	"testing"
+	"time"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type timerTick struct {
+	label string
+}
+
+type cancelTimer struct{}
+
+type scheduling struct {
+	report   actors.Pid
+	interval bool
+	timer    actors.TimerRef
+}
+
+func (s *scheduling) OnMessage(r actors.Runtime, m any) {
+	switch msg := m.(type) {
+	case *actors.Start:
+		if s.interval {
+			s.timer = r.SendInterval(time.Millisecond, timerTick{label: "interval"})
+		} else {
+			s.timer = r.SendAfter(time.Millisecond, timerTick{label: "after"})
+		}
+	case cancelTimer:
+		r.Tell(s.report, s.timer.Cancel())
+	case string:
+		r.Exit(msg)
+	case timerTick:
+		r.Tell(s.report, msg)
+	}
+}
+
+// stalledTicker blocks on the first tick of an interval timer until released.
+type stalledTicker struct {
+	gate    chan struct{}
+	release chan struct{}
+}
+
+func (s *stalledTicker) OnMessage(r actors.Runtime, m any) {
+	switch m.(type) {
+	case *actors.Start:
+		r.SendInterval(time.Millisecond, timerTick{label: "stalled"})
+	case timerTick:
+		if s.gate != nil {
+			close(s.gate)
+			s.gate = nil
+			<-s.release
+		}
+	}
+}
+
+func TestTimers(t *testing.T) {
+	t.Parallel()
+
+	t.Run("SendAfter delivers once", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		port := sys.NewPort()
+		pid := sys.Spawn(ctx, &scheduling{report: port.Pid()})
+
+		msg, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, timerTick{label: "after"}, msg)
+
+		sys.Tell(ctx, pid, cancelTimer{})
+		cancelled, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, false, cancelled, "fired timers may not be cancelled")
+	})
+
+	t.Run("SendInterval delivers until cancelled", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		port := sys.NewPort()
+		pid := sys.Spawn(ctx, &scheduling{report: port.Pid(), interval: true})
+
+		for i := 0; i < 3; i++ {
+			msg, err := port.ReceiveWith(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, timerTick{label: "interval"}, msg)
+		}
+
+		sys.Tell(ctx, pid, cancelTimer{})
+		for {
+			msg, err := port.ReceiveWith(ctx)
+			require.NoError(t, err)
+			if cancelled, ok := msg.(bool); ok {
+				assert.True(t, cancelled)
+				break
+			}
+		}
+		_, err := port.ReceiveTimeout(10 * time.Millisecond)
+		assert.Error(t, err, "no ticks after cancellation")
+	})
+
+	t.Run("Timers are cancelled on exit", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		port := sys.NewPort()
+		actor := &scheduling{report: port.Pid(), interval: true}
+		pid := sys.Spawn(ctx, actor, actors.MonitorOpt{Tell: port.Pid()})
+		sys.Tell(ctx, pid, "exit")
+
+		for {
+			msg, err := port.ReceiveWith(ctx)
+			require.NoError(t, err)
+			if _, ok := msg.(actors.NormalExit); ok {
+				break
+			}
+		}
+		assert.False(t, actor.timer.Cancel(), "timer already cancelled by exit")
+	})
+
+	t.Run("Firings rejected by a full mailbox are dead lettered", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		sys := NewSystem()
+		letters := sys.NewPort()
+		_, err := ForwardDeadLetters(sys, letters.Pid())
+		require.NoError(t, err)
+
+		actor := &stalledTicker{gate: make(chan struct{}), release: make(chan struct{})}
+		gate := actor.gate
+		port := sys.NewPort()
+		pid := sys.Spawn(ctx, actor, actors.MailboxOpt{Capacity: 1, Overflow: actors.OverflowFail}, actors.MonitorOpt{Tell: port.Pid()})
+		<-gate
+
+		letter, err := letters.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, actors.DeadLetter{Target: pid, Message: timerTick{label: "stalled"}, Reason: actors.DeadLetterMailboxFull}, letter)
+		close(actor.release)
+
+		require.NoError(t, sys.Shutdown(ctx))
+		exit, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, actors.NormalExit{Who: pid, ExitValue: actors.Stopping{}}, exit, "the actor survives the rejected firings")
+	})
+}
@@ -0,0 +1,211 @@
+package local
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"go.opentelemetry.io/otel"
+)
+
+type frameKind uint8
+
+const (
+	frameTell frameKind = iota
+	frameNormalExit
+	framePanicExit
+	frameMonitor
+	frameUnmonitor
+	frameTerminate
+	frameLink
+	frameUnlink
+	frameLinkExit
+)
+
+// wireHello is the first value exchanged on a new link, identifying the node on each end.
+type wireHello struct {
+	Node uint64
+}
+
+// wireFrame is the envelope for all traffic between two nodes.
+type wireFrame struct {
+	Kind frameKind
+	//To is the targeted Pid on the receiving node
+	To actors.Pid
+	//Peer is the secondary Pid for the frame: the watcher for monitors or the exiting actor for exits
+	Peer actors.Pid
+	//Trace is the propagated tracing context
+	Trace map[string]string
+	//Payload is the encoded message or exit value
+	Payload []byte
+	//Momento is the encoded monitor momento
+	Momento []byte
+	//Abnormal indicates a linked exit is abnormal
+	Abnormal bool
+}
+
+func (w *wireFrame) Get(key string) string {
+	return w.Trace[key]
+}
+
+func (w *wireFrame) Set(key string, value string) {
+	w.Trace[key] = value
+}
+
+func (w *wireFrame) Keys() []string {
+	out := make([]string, 0, len(w.Trace))
+	for k := range w.Trace {
+		out = append(out, k)
+	}
+	return out
+}
+
+func (w *wireFrame) inject(ctx context.Context) {
+	w.Trace = make(map[string]string)
+	otel.GetTextMapPropagator().Inject(ctx, w)
+}
+
+func (w *wireFrame) extract(ctx context.Context) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, w)
+}
+
+// payloadCodec converts message values to and from the bytes carried within a wireFrame.
+type payloadCodec interface {
+	encode(value any) ([]byte, error)
+	decode(data []byte) (any, error)
+}
+
+type gobEnvelope struct {
+	Value any
+}
+
+func init() {
+	//exit values and reasons produced by the system itself
+	gob.Register(actors.NoProcess{})
+	gob.Register(actors.Terminated{})
+	gob.Register(actors.Stopping{})
+}
+
+// gobPayloads encodes payloads with encoding/gob.  Concrete message types must be registered via gob.Register.  Used
+// when the system is not configured with a CodecOpt.
+type gobPayloads struct{}
+
+func (g gobPayloads) encode(value any) ([]byte, error) {
+	var out bytes.Buffer
+	if err := gob.NewEncoder(&out).Encode(gobEnvelope{Value: value}); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func (g gobPayloads) decode(data []byte) (any, error) {
+	var envelope gobEnvelope
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&envelope); err != nil {
+		return nil, err
+	}
+	return envelope.Value, nil
+}
+
+// remoteSignal is implemented by runtimeMessage types which may be forwarded to another node.
+type remoteSignal interface {
+	frame(codec payloadCodec) (wireFrame, error)
+}
+
+func (s *startMonitoring) frame(codec payloadCodec) (wireFrame, error) {
+	momento, err := codec.encode(s.what)
+	if err != nil {
+		return wireFrame{}, err
+	}
+	return wireFrame{Kind: frameMonitor, Peer: s.listener, Momento: momento}, nil
+}
+
+func (s *stopMonitoring) frame(codec payloadCodec) (wireFrame, error) {
+	return wireFrame{Kind: frameUnmonitor, Peer: s.listener}, nil
+}
+
+func (t *terminateSignal) frame(codec payloadCodec) (wireFrame, error) {
+	return wireFrame{Kind: frameTerminate}, nil
+}
+
+func (l *linkSignal) frame(codec payloadCodec) (wireFrame, error) {
+	return wireFrame{Kind: frameLink, Peer: l.with}, nil
+}
+
+func (u *unlinkSignal) frame(codec payloadCodec) (wireFrame, error) {
+	return wireFrame{Kind: frameUnlink, Peer: u.with}, nil
+}
+
+// frame encodes the exit reason when possible.  Reasons are frequently arbitrary panic values so the exit is still
+// delivered, without a reason, when the reason may not be encoded.
+func (l *linkExitSignal) frame(codec payloadCodec) (wireFrame, error) {
+	reason, err := encodeOrNil(codec, l.reason)
+	if err != nil {
+		return wireFrame{}, err
+	}
+	return wireFrame{Kind: frameLinkExit, Peer: l.from, Payload: reason, Abnormal: l.abnormal}, nil
+}
+
+// encodeOrNil encodes value, substituting nil when value may not be encoded.
+func encodeOrNil(codec payloadCodec, value any) ([]byte, error) {
+	encoded, err := codec.encode(value)
+	if err != nil {
+		return codec.encode(nil)
+	}
+	return encoded, nil
+}
+
+// messageFrame builds the frame for a user message, giving exit notifications their own frames so the momento and exit
+// values are encoded independently of the notification itself.  Exit notifications are always delivered, without the
+// values which may not be encoded, so remote watchers learn of the exit.
+func messageFrame(codec payloadCodec, m any) (wireFrame, error) {
+	switch msg := m.(type) {
+	case actors.NormalExit:
+		payload, err := encodeOrNil(codec, msg.ExitValue)
+		if err != nil {
+			return wireFrame{}, err
+		}
+		momento, err := encodeOrNil(codec, msg.Momento)
+		if err != nil {
+			return wireFrame{}, err
+		}
+		return wireFrame{Kind: frameNormalExit, Peer: msg.Who, Payload: payload, Momento: momento}, nil
+	case actors.PanicExit:
+		momento, err := encodeOrNil(codec, msg.Momento)
+		if err != nil {
+			return wireFrame{}, err
+		}
+		return wireFrame{Kind: framePanicExit, Peer: msg.Who, Momento: momento}, nil
+	default:
+		payload, err := codec.encode(m)
+		if err != nil {
+			return wireFrame{}, err
+		}
+		return wireFrame{Kind: frameTell, Payload: payload}, nil
+	}
+}
+
+// registryPayloads encodes payloads through a codec registry, rejecting unregistered message types.
+type registryPayloads struct {
+	registry *actors.CodecRegistry
+}
+
+func (r registryPayloads) encode(value any) ([]byte, error) {
+	encoded, err := r.registry.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := gob.NewEncoder(&out).Encode(encoded); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func (r registryPayloads) decode(data []byte) (any, error) {
+	var encoded actors.EncodedMessage
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&encoded); err != nil {
+		return nil, err
+	}
+	return r.registry.Decode(encoded)
+}
@@ -0,0 +1,71 @@
+package actors
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// StructuredLogger records messages with key-value attributes at a level.  Attributes are given as alternating keys
+// and values or as slog.Attr, as interpreted by slog.Logger.Log.
+type StructuredLogger interface {
+	//Enabled reports whether records at level are recorded, allowing expensive attributes to be skipped
+	Enabled(level slog.Level) bool
+	//Log records msg at level with the attributes of args
+	Log(level slog.Level, msg string, args ...any)
+	//With creates a logger adding the attributes of args to every record
+	With(args ...any) StructuredLogger
+}
+
+// Structured provides the structured interface of l.  The loggers of the actor systems within this module implement
+// StructuredLogger directly; other loggers are adapted by rendering the attributes into the message.
+func Structured(l Logger) StructuredLogger {
+	if structured, ok := l.(StructuredLogger); ok {
+		return structured
+	}
+	return &structuredAdapter{logger: l}
+}
+
+// FormatStructured renders msg followed by the attributes of args as key=value pairs, normalizing args as log/slog
+// does.
+func FormatStructured(msg string, args ...any) string {
+	record := slog.NewRecord(time.Time{}, slog.LevelInfo, msg, 0)
+	record.Add(args...)
+	var out strings.Builder
+	out.WriteString(msg)
+	record.Attrs(func(attr slog.Attr) bool {
+		fmt.Fprintf(&out, " %s=%s", attr.Key, attr.Value.Resolve())
+		return true
+	})
+	return out.String()
+}
+
+// structuredAdapter records structured messages through the printf style methods of a Logger.
+type structuredAdapter struct {
+	logger Logger
+	attrs  []any
+}
+
+func (s *structuredAdapter) Enabled(level slog.Level) bool {
+	return level >= slog.LevelInfo
+}
+
+func (s *structuredAdapter) Log(level slog.Level, msg string, args ...any) {
+	if !s.Enabled(level) {
+		return
+	}
+	line := FormatStructured(msg, append(append([]any{}, s.attrs...), args...)...)
+	switch {
+	case level >= slog.LevelError:
+		s.logger.Error("%s", line)
+	case level >= slog.LevelWarn:
+		s.logger.Warn("%s", line)
+	default:
+		s.logger.Info("%s", line)
+	}
+}
+
+func (s *structuredAdapter) With(args ...any) StructuredLogger {
+	return &structuredAdapter{logger: s.logger, attrs: append(append([]any{}, s.attrs...), args...)}
+}
@@ -0,0 +1,40 @@
+package actors
+
+import (
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// printfLogger records each line written through the printf style methods.
+type printfLogger struct {
+	lines []string
+}
+
+func (p *printfLogger) write(level, format string, args []any) {
+	p.lines = append(p.lines, level+" "+fmt.Sprintf(format, args...))
+}
+
+func (p *printfLogger) Error(format string, args ...any) { p.write("error", format, args) }
+func (p *printfLogger) Fatal(format string, args ...any) { panic(fmt.Sprintf(format, args...)) }
+func (p *printfLogger) Info(format string, args ...any)  { p.write("info", format, args) }
+func (p *printfLogger) Warn(format string, args ...any)  { p.write("warn", format, args) }
+
+func TestStructured(t *testing.T) {
+	t.Parallel()
+	out := &printfLogger{}
+	logger := Structured(out).With("pid", Pid{Process: 3})
+	assert.False(t, logger.Enabled(slog.LevelDebug))
+	logger.Log(slog.LevelDebug, "filtered")
+	logger.Log(slog.LevelInfo, "started", "count", 2, slog.Bool("ok", true))
+	logger.Log(slog.LevelWarn, "slow")
+	logger.Log(slog.LevelError+4, "odd", "dangling")
+
+	assert.Equal(t, []string{
+		"info started pid=<0.3> count=2 ok=true",
+		"warn slow pid=<0.3>",
+		"error odd pid=<0.3> !BADKEY=dangling",
+	}, out.lines)
+}
@@ -1,5 +1,7 @@
 package actors
 
+import "fmt"
+
 // Start indicates the actor is starting execution and should perform any in actor initialization
 type Start struct{}
 
@@ -27,3 +29,87 @@ type NormalExit struct {
 	//Momento is state details
 	Momento any
 }
+
+// LinkExit is delivered to actors trapping exits when a linked actor exits.  Like other messages it arrives after any
+// message the linked actor sent before exiting.
+type LinkExit struct {
+	//Who is the linked actor which exited
+	Who Pid
+	//Reason is the exit value for normal exits, otherwise the cause of the abnormal exit
+	Reason any
+	//Abnormal is true when the linked actor panicked or was terminated
+	Abnormal bool
+}
+
+// Terminated is the exit value given to monitors and the reason given to linked actors when an actor is stopped via
+// Runtime.Terminate.
+type Terminated struct{}
+
+// NoProcess is the reason given when linking to an actor which does not exist or is no longer reachable.
+type NoProcess struct{}
+
+// Stopping is delivered to each actor as the system shuts down.  Once handled the actor exits normally with Stopping
+// as the exit value.
+type Stopping struct{}
+
+// ShutdownError lists the actors which did not stop before the shutdown deadline and were forcibly closed.
+type ShutdownError struct {
+	Remaining []Pid
+}
+
+func (s *ShutdownError) Error() string {
+	return fmt.Sprintf("%d actors did not stop in time: %v", len(s.Remaining), s.Remaining)
+}
+
+// DeadLetterReason describes why a message could not be delivered.
+type DeadLetterReason uint8
+
+const (
+	//DeadLetterNoTarget indicates no actor or port exists for the target
+	DeadLetterNoTarget DeadLetterReason = iota
+	//DeadLetterTargetDone indicates the target exited before the message was consumed
+	DeadLetterTargetDone
+	//DeadLetterPortClosed indicates the targeted port was closed
+	DeadLetterPortClosed
+	//DeadLetterMailboxOverflow indicates the target's overflow policy discarded or rejected the message
+	DeadLetterMailboxOverflow
+	//DeadLetterNoRoute indicates the node hosting the target is not linked
+	DeadLetterNoRoute
+	//DeadLetterMailboxFull indicates the mailbox of the target actor or port was full and the message was sent from
+	//outside of an actor or port, such as by a timer or a node link, which may neither block nor fail
+	DeadLetterMailboxFull
+	//DeadLetterUnencodable indicates the message could not be encoded for the node hosting the target and was sent from
+	//outside of an actor
+	DeadLetterUnencodable
+)
+
+func (d DeadLetterReason) String() string {
+	switch d {
+	case DeadLetterNoTarget:
+		return "no-target"
+	case DeadLetterTargetDone:
+		return "target-done"
+	case DeadLetterPortClosed:
+		return "port-closed"
+	case DeadLetterMailboxOverflow:
+		return "mailbox-overflow"
+	case DeadLetterNoRoute:
+		return "no-route"
+	case DeadLetterMailboxFull:
+		return "mailbox-full"
+	case DeadLetterUnencodable:
+		return "unencodable"
+	default:
+		return fmt.Sprintf("DeadLetterReason(%d)", uint8(d))
+	}
+}
+
+// DeadLetter is a message the system was unable to deliver.
+type DeadLetter struct {
+	//Target is the intended recipient
+	Target Pid
+	//Sender is the actor or port which sent the message, or the zero Pid when sent from outside the system
+	Sender  Pid
+	Message any
+	Reason  DeadLetterReason
+}
@@ -0,0 +1,207 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+)
+
+// Handler describes a persistent actor whose state of type S is built by applying events.
+type Handler[S any] interface {
+	//Apply folds event into state.  Invoked for each newly persisted event and for each event replayed during
+	//recovery, so must not produce side effects.
+	Apply(state *S, event any)
+	//OnCommand handles messages delivered to the actor, including actors.Start once recovery has completed.  Changes
+	//to the state are made by persisting events through ctx.
+	OnCommand(ctx *Context[S], m any)
+}
+
+// Options configures a persistent actor.
+type Options struct {
+	//ID identifies the actor's events within the Journal.  Restarted actors recover from the events of the same ID.
+	ID      string
+	Journal Journal
+	//Codecs encodes the events and snapshots; both the event types and the state type must be registered
+	Codecs *actors.CodecRegistry
+	//SnapshotEvery saves a snapshot after the given number of events.  Zero disables automatic snapshots.
+	SnapshotEvery uint64
+}
+
+// RecoveryError indicates the actor's state could not be recovered from the journal.
+type RecoveryError struct {
+	ID    string
+	Cause error
+}
+
+func (r *RecoveryError) Error() string {
+	return fmt.Sprintf("recovering %q: %s", r.ID, r.Cause)
+}
+
+func (r *RecoveryError) Unwrap() error {
+	return r.Cause
+}
+
+// WrongStateError indicates a snapshot decoded to a type other than the state of the actor.
+type WrongStateError struct {
+	Expected reflect.Type
+	Decoded  any
+}
+
+func (w *WrongStateError) Error() string {
+	return fmt.Sprintf("snapshot decoded to %T, expected %s", w.Decoded, w.Expected)
+}
+
+// FromHandler creates an actor which recovers its state from the journal upon actors.Start, before the handler
+// receives any message.  Recovery failures panic within the actor, allowing a supervisor to retry.
+func FromHandler[S any](opts Options, handler Handler[S]) actors.MessageActor {
+	return &actor[S]{opts: opts, handler: handler}
+}
+
+type actor[S any] struct {
+	opts    Options
+	handler Handler[S]
+	state   S
+	//sequence is the sequence of the last applied event
+	sequence uint64
+	//snapshotAt is the sequence of the last snapshot
+	snapshotAt uint64
+	//unsettled is true when a failed Append may have stored records which have not been applied
+	unsettled bool
+}
+
+func (a *actor[S]) OnMessage(r actors.Runtime, m any) {
+	if _, ok := m.(*actors.Start); ok {
+		if err := a.recover(r); err != nil {
+			panic(&RecoveryError{ID: a.opts.ID, Cause: err})
+		}
+	}
+	a.handler.OnCommand(&Context[S]{Runtime: r, actor: a}, m)
+}
+
+// recover restores the latest snapshot then applies the events persisted after it.
+func (a *actor[S]) recover(r actors.Runtime) error {
+	var zero S
+	a.state, a.sequence, a.snapshotAt = zero, 0, 0
+	ctx := r.Context()
+	snapshot, has, err := a.opts.Journal.LatestSnapshot(ctx, a.opts.ID)
+	if err != nil {
+		return err
+	}
+	if has {
+		decoded, err := a.opts.Codecs.Decode(snapshot.State)
+		if err != nil {
+			return err
+		}
+		state, ok := decoded.(S)
+		if !ok {
+			return &WrongStateError{Expected: reflect.TypeFor[S](), Decoded: decoded}
+		}
+		a.state = state
+		a.sequence = snapshot.Sequence
+		a.snapshotAt = snapshot.Sequence
+	}
+
+	return a.replay(ctx)
+}
+
+// replay applies the events stored after the last applied event.
+func (a *actor[S]) replay(ctx context.Context) error {
+	records, err := a.opts.Journal.Read(ctx, a.opts.ID, a.sequence)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		event, err := a.opts.Codecs.Decode(record.Event)
+		if err != nil {
+			return err
+		}
+		a.handler.Apply(&a.state, event)
+		a.sequence = record.Sequence
+	}
+	return nil
+}
+
+// settle applies any records stored by a failed Append, ensuring their sequences are never reused.
+func (a *actor[S]) settle(ctx context.Context) error {
+	if !a.unsettled {
+		return nil
+	}
+	if err := a.replay(ctx); err != nil {
+		return err
+	}
+	a.unsettled = false
+	return nil
+}
+
+func (a *actor[S]) persist(ctx *Context[S], events []any) error {
+	if err := a.settle(ctx.Context()); err != nil {
+		return err
+	}
+	records := make([]Record, len(events))
+	for index, event := range events {
+		encoded, err := a.opts.Codecs.Encode(event)
+		if err != nil {
+			return err
+		}
+		records[index] = Record{Sequence: a.sequence + uint64(index) + 1, Event: encoded}
+	}
+	if err := a.opts.Journal.Append(ctx.Context(), a.opts.ID, records); err != nil {
+		a.unsettled = true
+		//retried by the next persist should the journal remain unreadable
+		_ = a.settle(ctx.Context())
+		return err
+	}
+	for _, event := range events {
+		a.handler.Apply(&a.state, event)
+		a.sequence++
+	}
+	if a.opts.SnapshotEvery > 0 && a.sequence-a.snapshotAt >= a.opts.SnapshotEvery {
+		//the events are already stored, so a failed snapshot must not be reported as a failed Persist which may be retried
+		if err := a.saveSnapshot(ctx); err != nil {
+			ctx.Log().Warn("snapshot of %q at %d failed: %s", a.opts.ID, a.sequence, err)
+		}
+	}
+	return nil
+}
+
+func (a *actor[S]) saveSnapshot(ctx *Context[S]) error {
+	encoded, err := a.opts.Codecs.Encode(a.state)
+	if err != nil {
+		return err
+	}
+	if err := a.opts.Journal.SaveSnapshot(ctx.Context(), a.opts.ID, Snapshot{Sequence: a.sequence, State: encoded}); err != nil {
+		return err
+	}
+	a.snapshotAt = a.sequence
+	return nil
+}
+
+// Context is the actors.Runtime of a persistent actor, extended with access to the persisted state.
+type Context[S any] struct {
+	actors.Runtime
+	actor *actor[S]
+}
+
+// State is the state resulting from all persisted events.  Modifications should be made through Persist.
+func (c *Context[S]) State() *S {
+	return &c.actor.state
+}
+
+// Sequence is the sequence of the last persisted event, or zero if no events have been persisted.
+func (c *Context[S]) Sequence() uint64 {
+	return c.actor.sequence
+}
+
+// Persist appends the events to the journal then applies them to the state.  If the events can not be appended only
+// those the journal stored before failing are applied.  Automatic snapshots failing are logged rather than returned,
+// as the events have been persisted; the snapshot is attempted again with the next Persist.
+func (c *Context[S]) Persist(events ...any) error {
+	return c.actor.persist(c, events)
+}
+
+// SaveSnapshot stores the current state, allowing recovery to skip the events already applied.
+func (c *Context[S]) SaveSnapshot() error {
+	return c.actor.saveSnapshot(c)
+}
@@ -0,0 +1,200 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/meschbach/go-junk-bucket/pkg/actors/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tally struct {
+	Total int
+}
+
+type added struct {
+	Amount int
+}
+
+type add struct {
+	amount int
+	report actors.Pid
+}
+
+type total struct {
+	report actors.Pid
+}
+
+type tallyHandler struct{}
+
+func (t *tallyHandler) Apply(state *tally, event any) {
+	if e, ok := event.(added); ok {
+		state.Total += e.Amount
+	}
+}
+
+func (t *tallyHandler) OnCommand(ctx *Context[tally], m any) {
+	switch msg := m.(type) {
+	case add:
+		ctx.Tell(msg.report, ctx.Persist(added{Amount: msg.amount}))
+	case total:
+		ctx.Tell(msg.report, ctx.State().Total)
+	}
+}
+
+func tallyCodecs(t *testing.T) *actors.CodecRegistry {
+	codecs := actors.NewCodecRegistry()
+	require.NoError(t, actors.RegisterMessage[added](codecs, "added", actors.JSONCodec))
+	require.NoError(t, actors.RegisterMessage[tally](codecs, "tally", actors.JSONCodec))
+	return codecs
+}
+
+// runTally spawns a tally actor within a new system, adding each amount then reporting the total.
+func runTally(t *testing.T, opts Options, amounts ...int) int {
+	ctx := t.Context()
+	sys := local.NewSystem()
+	defer func() {
+		require.NoError(t, sys.Shutdown(ctx))
+	}()
+	port := sys.NewPort()
+	pid := sys.Spawn(ctx, FromHandler[tally](opts, &tallyHandler{}))
+	for _, amount := range amounts {
+		sys.Tell(ctx, pid, add{amount: amount, report: port.Pid()})
+		result, err := port.ReceiveWith(ctx)
+		require.NoError(t, err)
+		require.Nil(t, result)
+	}
+	sys.Tell(ctx, pid, total{report: port.Pid()})
+	result, err := port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	return result.(int)
+}
+
+func TestRecovery(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Events are replayed on start", func(t *testing.T) {
+		t.Parallel()
+		journal, err := NewFileJournal(t.TempDir())
+		require.NoError(t, err)
+		opts := Options{ID: "tally", Journal: journal, Codecs: tallyCodecs(t)}
+
+		assert.Equal(t, 3, runTally(t, opts, 1, 2))
+		assert.Equal(t, 7, runTally(t, opts, 4))
+	})
+
+	t.Run("Snapshots are restored before the journal tail", func(t *testing.T) {
+		t.Parallel()
+		journal, err := NewFileJournal(t.TempDir())
+		require.NoError(t, err)
+		opts := Options{ID: "tally", Journal: journal, Codecs: tallyCodecs(t), SnapshotEvery: 2}
+
+		assert.Equal(t, 6, runTally(t, opts, 1, 2, 3))
+		snapshot, has, err := journal.LatestSnapshot(t.Context(), "tally")
+		require.NoError(t, err)
+		require.True(t, has)
+		assert.Equal(t, uint64(2), snapshot.Sequence)
+
+		assert.Equal(t, 6, runTally(t, opts))
+	})
+}
+
+type failingJournal struct {
+	Journal
+}
+
+func (f *failingJournal) Append(ctx context.Context, id string, records []Record) error {
+	return errors.New("disk full")
+}
+
+func TestPersistFailure(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	journal, err := NewFileJournal(t.TempDir())
+	require.NoError(t, err)
+	opts := Options{ID: "tally", Journal: &failingJournal{Journal: journal}, Codecs: tallyCodecs(t)}
+
+	sys := local.NewSystem()
+	port := sys.NewPort()
+	pid := sys.Spawn(ctx, FromHandler[tally](opts, &tallyHandler{}))
+	sys.Tell(ctx, pid, add{amount: 5, report: port.Pid()})
+	result, err := port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	assert.EqualError(t, result.(error), "disk full")
+
+	sys.Tell(ctx, pid, total{report: port.Pid()})
+	result, err = port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result, "events which were not appended are not applied")
+}
+
+// partialJournal stores only the first record of the first Append before failing.
+type partialJournal struct {
+	Journal
+	failed bool
+}
+
+func (p *partialJournal) Append(ctx context.Context, id string, records []Record) error {
+	if p.failed {
+		return p.Journal.Append(ctx, id, records)
+	}
+	p.failed = true
+	return errors.Join(p.Journal.Append(ctx, id, records[:1]), errors.New("disk full"))
+}
+
+func TestPartialPersistFailure(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	journal, err := NewFileJournal(t.TempDir())
+	require.NoError(t, err)
+	opts := Options{ID: "tally", Journal: &partialJournal{Journal: journal}, Codecs: tallyCodecs(t)}
+
+	sys := local.NewSystem()
+	port := sys.NewPort()
+	pid := sys.Spawn(ctx, FromHandler[tally](opts, &tallyHandler{}))
+	sys.Tell(ctx, pid, add{amount: 5, report: port.Pid()})
+	result, err := port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	assert.EqualError(t, result.(error), "disk full")
+	sys.Tell(ctx, pid, add{amount: 2, report: port.Pid()})
+	result, err = port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	require.Nil(t, result)
+
+	sys.Tell(ctx, pid, total{report: port.Pid()})
+	result, err = port.ReceiveWith(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 7, result, "stored events are applied")
+	require.NoError(t, sys.Shutdown(ctx))
+
+	records, err := journal.Read(ctx, "tally", 0)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, []uint64{1, 2}, []uint64{records[0].Sequence, records[1].Sequence})
+	assert.Equal(t, 7, runTally(t, Options{ID: "tally", Journal: journal, Codecs: tallyCodecs(t)}), "recovery replays no duplicates")
+}
+
+// snapshotlessJournal fails every snapshot.
+type snapshotlessJournal struct {
+	Journal
+}
+
+func (s *snapshotlessJournal) SaveSnapshot(ctx context.Context, id string, snapshot Snapshot) error {
+	return errors.New("snapshots unavailable")
+}
+
+func TestSnapshotFailure(t *testing.T) {
+	t.Parallel()
+	journal, err := NewFileJournal(t.TempDir())
+	require.NoError(t, err)
+	opts := Options{ID: "tally", Journal: &snapshotlessJournal{Journal: journal}, Codecs: tallyCodecs(t), SnapshotEvery: 1}
+
+	assert.Equal(t, 3, runTally(t, opts, 1, 2), "persisting succeeds despite the snapshot failing")
+	records, err := journal.Read(t.Context(), "tally", 0)
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, 3, runTally(t, opts))
+}
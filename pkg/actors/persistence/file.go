@@ -0,0 +1,156 @@
+package persistence
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/meschbach/go-junk-bucket/pkg/files"
+)
+
+// FileJournal stores each persistent actor's events as JSON lines within a directory, alongside a JSON snapshot.
+// Safe for use by multiple goroutines within a process; the directory must not be shared between processes.  A final
+// line which is not terminated or may not be decoded was torn by a crash during Append, so is treated as never written.
+type FileJournal struct {
+	directory string
+	lock      sync.Mutex
+}
+
+// NewFileJournal creates a journal within directory, creating the directory if required.
+func NewFileJournal(directory string) (*FileJournal, error) {
+	if err := os.MkdirAll(directory, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileJournal{directory: directory}, nil
+}
+
+func (f *FileJournal) eventsFile(id string) string {
+	return filepath.Join(f.directory, url.PathEscape(id)+".events")
+}
+
+func (f *FileJournal) snapshotFile(id string) string {
+	return filepath.Join(f.directory, url.PathEscape(id)+".snapshot.json")
+}
+
+func (f *FileJournal) Append(ctx context.Context, id string, records []Record) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	fileName := f.eventsFile(id)
+	file, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return &files.FileContentsError{FileName: fileName, Underlying: err}
+	}
+	if err = truncateTorn(file); err != nil {
+		return &files.FileContentsError{FileName: fileName, Underlying: errors.Join(err, file.Close())}
+	}
+	out := bufio.NewWriter(file)
+	encoder := json.NewEncoder(out)
+	for _, record := range records {
+		if err = encoder.Encode(record); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = out.Flush()
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if err = errors.Join(err, file.Close()); err != nil {
+		return &files.FileContentsError{FileName: fileName, Underlying: err}
+	}
+	return nil
+}
+
+func (f *FileJournal) Read(ctx context.Context, id string, after uint64) ([]Record, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	fileName := f.eventsFile(id)
+	file, err := os.Open(fileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, &files.FileContentsError{FileName: fileName, Underlying: err}
+	}
+	defer file.Close()
+
+	var out []Record
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			//an unterminated final line is torn
+			return out, nil
+		} else if err != nil {
+			return nil, &files.FileContentsError{FileName: fileName, Underlying: err}
+		}
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			if _, peekErr := reader.Peek(1); errors.Is(peekErr, io.EOF) {
+				return out, nil
+			}
+			return nil, &files.FileContentsError{FileName: fileName, Underlying: err}
+		}
+		if record.Sequence > after {
+			out = append(out, record)
+		}
+	}
+}
+
+// truncateTorn removes a torn final line so further records are not written after it.
+func truncateTorn(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+	if size == 0 {
+		return nil
+	}
+	//the final line begins after the last newline preceding the final byte
+	var tail []byte
+	chunk := make([]byte, 4096)
+	start := size
+	for start > 0 {
+		length := min(int64(len(chunk)), start)
+		start -= length
+		if _, err := file.ReadAt(chunk[:length], start); err != nil {
+			return err
+		}
+		tail = append(append([]byte(nil), chunk[:length]...), tail...)
+		if index := bytes.LastIndexByte(tail[:len(tail)-1], '\n'); index >= 0 {
+			tail = tail[index+1:]
+			break
+		}
+	}
+	var record Record
+	if tail[len(tail)-1] == '\n' && json.Unmarshal(tail, &record) == nil {
+		return nil
+	}
+	return file.Truncate(size - int64(len(tail)))
+}
+
+func (f *FileJournal) SaveSnapshot(ctx context.Context, id string, snapshot Snapshot) error {
+	return files.WriteJSONFile(f.snapshotFile(id), snapshot)
+}
+
+func (f *FileJournal) LatestSnapshot(ctx context.Context, id string) (Snapshot, bool, error) {
+	var snapshot Snapshot
+	fileName := f.snapshotFile(id)
+	if _, err := os.Stat(fileName); errors.Is(err, os.ErrNotExist) {
+		return snapshot, false, nil
+	}
+	if err := files.ParseJSONFile(fileName, &snapshot); err != nil {
+		return snapshot, false, err
+	}
+	return snapshot, true, nil
+}
@@ -0,0 +1,76 @@
+package persistence
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileJournal(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	journal, err := NewFileJournal(t.TempDir())
+	require.NoError(t, err)
+
+	records, err := journal.Read(ctx, "a/b", 0)
+	require.NoError(t, err)
+	assert.Empty(t, records, "unknown IDs have no events")
+	_, has, err := journal.LatestSnapshot(ctx, "a/b")
+	require.NoError(t, err)
+	assert.False(t, has, "unknown IDs have no snapshot")
+
+	first := Record{Sequence: 1, Event: actors.EncodedMessage{Type: "e", Codec: "json", Data: []byte("1")}}
+	second := Record{Sequence: 2, Event: actors.EncodedMessage{Type: "e", Codec: "json", Data: []byte("2")}}
+	require.NoError(t, journal.Append(ctx, "a/b", []Record{first}))
+	require.NoError(t, journal.Append(ctx, "a/b", []Record{second}))
+
+	records, err = journal.Read(ctx, "a/b", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []Record{first, second}, records)
+	records, err = journal.Read(ctx, "a/b", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []Record{second}, records)
+
+	snapshot := Snapshot{Sequence: 2, State: actors.EncodedMessage{Type: "s", Codec: "json", Data: []byte("{}")}}
+	require.NoError(t, journal.SaveSnapshot(ctx, "a/b", snapshot))
+	restored, has, err := journal.LatestSnapshot(ctx, "a/b")
+	require.NoError(t, err)
+	assert.True(t, has)
+	assert.Equal(t, snapshot, restored)
+}
+
+func TestFileJournalTornAppend(t *testing.T) {
+	t.Parallel()
+	for name, torn := range map[string]string{
+		"unterminated": `{"Sequence":2,"Ev`,
+		"undecodable":  "{\"Sequence\":2,\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := t.Context()
+			journal, err := NewFileJournal(t.TempDir())
+			require.NoError(t, err)
+
+			first := Record{Sequence: 1, Event: actors.EncodedMessage{Type: "e", Codec: "json", Data: []byte("1")}}
+			second := Record{Sequence: 2, Event: actors.EncodedMessage{Type: "e", Codec: "json", Data: []byte("2")}}
+			require.NoError(t, journal.Append(ctx, "torn", []Record{first}))
+			file, err := os.OpenFile(journal.eventsFile("torn"), os.O_APPEND|os.O_WRONLY, 0o600)
+			require.NoError(t, err)
+			_, err = file.WriteString(torn)
+			require.NoError(t, errors.Join(err, file.Close()))
+
+			records, err := journal.Read(ctx, "torn", 0)
+			require.NoError(t, err)
+			assert.Equal(t, []Record{first}, records, "torn records are not read")
+
+			require.NoError(t, journal.Append(ctx, "torn", []Record{second}))
+			records, err = journal.Read(ctx, "torn", 0)
+			require.NoError(t, err)
+			assert.Equal(t, []Record{first, second}, records, "appends replace torn records")
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package persistence
+
+import (
+	"context"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+)
+
+// Record is an event persisted within a journal.
+type Record struct {
+	//Sequence orders the events of a persistent actor, starting at 1
+	Sequence uint64
+	Event    actors.EncodedMessage
+}
+
+// Snapshot is the state of a persistent actor after applying all events up to and including Sequence.
+type Snapshot struct {
+	Sequence uint64
+	State    actors.EncodedMessage
+}
+
+// Journal stores the events and snapshots of persistent actors, each identified by a persistence ID.  Journals deal in
+// encoded messages so stores need no knowledge of the actor's types.
+type Journal interface {
+	//Append durably stores the records, which are in sequence order.  A failed Append may have stored a prefix of the
+	//records.
+	Append(ctx context.Context, id string, records []Record) error
+	//Read provides all records with a sequence greater than after, in sequence order
+	Read(ctx context.Context, id string, after uint64) ([]Record, error)
+	//SaveSnapshot replaces the latest snapshot
+	SaveSnapshot(ctx context.Context, id string, snapshot Snapshot) error
+	//LatestSnapshot provides the most recent snapshot, returning false if none has been saved
+	LatestSnapshot(ctx context.Context, id string) (Snapshot, bool, error)
+}
@@ -1,13 +1,38 @@
 package registry
 
-import "github.com/meschbach/go-junk-bucket/pkg/actors"
+import (
+	"path"
+	"slices"
+	"sort"
+	"strings"
 
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+)
+
+// Registry binds names to processes and tracks groups of processes.  Registered processes, group members and watchers
+// are monitored, removing their names, memberships and subscriptions once they exit.
 type Registry struct {
 	named map[string]actors.Pid
+	//names are the names bound to each process
+	names    map[actors.Pid]map[string]struct{}
+	watchers map[actors.Pid]struct{}
+	//groups are the members of each group in the order they joined
+	groups map[string][]actors.Pid
+	//memberOf are the groups joined by each process
+	memberOf map[actors.Pid]map[string]struct{}
+	//monitoring are the processes monitored by the registry
+	monitoring map[actors.Pid]struct{}
 }
 
 func NewRegistry() *Registry {
-	return &Registry{named: make(map[string]actors.Pid)}
+	return &Registry{
+		named:      make(map[string]actors.Pid),
+		names:      make(map[actors.Pid]map[string]struct{}),
+		watchers:   make(map[actors.Pid]struct{}),
+		groups:     make(map[string][]actors.Pid),
+		memberOf:   make(map[actors.Pid]map[string]struct{}),
+		monitoring: make(map[actors.Pid]struct{}),
+	}
 }
 
 func (r *Registry) OnMessage(bif actors.Runtime, m any) {
@@ -22,6 +47,27 @@ func (r *Registry) OnMessage(bif actors.Runtime, m any) {
 		r.lookup(bif, msg)
 	case actors.RpcCall[Queryable, LookupResult]:
 		msg.Perform(bif, r)
+	case Join:
+		r.join(bif, msg)
+	case Leave:
+		r.leave(bif, msg.Group, msg.Who)
+	case Members:
+		bif.Tell(msg.Tell, GroupMembers{Group: msg.Group, Members: slices.Clone(r.groups[msg.Group])})
+	case Broadcast:
+		for _, member := range r.groups[msg.Group] {
+			bif.Tell(member, msg.Message)
+		}
+	case Query:
+		r.query(bif, msg)
+	case Watch:
+		r.watch(bif, msg)
+	case Unwatch:
+		delete(r.watchers, msg.Observer)
+		r.release(bif, msg.Observer)
+	case actors.NormalExit:
+		r.exited(bif, msg.Who)
+	case actors.PanicExit:
+		r.exited(bif, msg.Who)
 	default:
 		bif.Log().Warn("Unknown registry message: %#v", msg)
 	}
@@ -29,10 +75,36 @@ func (r *Registry) OnMessage(bif actors.Runtime, m any) {
 
 func (r *Registry) register(bif actors.Runtime, msg Register) {
 	name := msg.Name
-	if _, contained := r.named[name]; contained {
+	previous, contained := r.named[name]
+	if contained && previous == msg.Who {
+		return
+	}
+	r.bind(bif, name, msg.Who)
+	if contained {
 		bif.Log().Warn("Name %q already registered, replacing", name)
+		r.unbind(bif, name, previous)
+		r.notify(bif, Replaced{Name: name, Previous: previous, Who: msg.Who})
+	} else {
+		r.notify(bif, Registered{Name: name, Who: msg.Who})
+	}
+}
+
+func (r *Registry) bind(bif actors.Runtime, name string, who actors.Pid) {
+	r.named[name] = who
+	if _, has := r.names[who]; !has {
+		r.names[who] = make(map[string]struct{})
+	}
+	r.names[who][name] = struct{}{}
+	r.monitor(bif, who)
+}
+
+// unbind removes name from the names of who, without changing the binding of the name itself.
+func (r *Registry) unbind(bif actors.Runtime, name string, who actors.Pid) {
+	delete(r.names[who], name)
+	if len(r.names[who]) == 0 {
+		delete(r.names, who)
+		r.release(bif, who)
 	}
-	r.named[name] = msg.Who
 }
 
 func (r *Registry) unregister(bif actors.Runtime, msg Unregister) {
@@ -42,11 +114,137 @@ func (r *Registry) unregister(bif actors.Runtime, msg Unregister) {
 	}
 	if who == msg.Who {
 		delete(r.named, msg.Name)
+		r.unbind(bif, msg.Name, who)
+		r.notify(bif, Unregistered{Name: msg.Name, Who: who})
 	} else {
 		bif.Log().Warn("Requested %q unregister but does not match Pid %s", msg.Name, msg.Who)
 	}
 }
 
+func (r *Registry) watch(bif actors.Runtime, msg Watch) {
+	r.watchers[msg.Observer] = struct{}{}
+	r.monitor(bif, msg.Observer)
+	names := make([]string, 0, len(r.named))
+	for name := range r.named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		bif.Tell(msg.Observer, Registered{Name: name, Who: r.named[name]})
+	}
+}
+
+func (r *Registry) notify(bif actors.Runtime, event any) {
+	for watcher := range r.watchers {
+		bif.Tell(watcher, event)
+	}
+}
+
+func (r *Registry) monitor(bif actors.Runtime, who actors.Pid) {
+	if _, has := r.monitoring[who]; has {
+		return
+	}
+	r.monitoring[who] = struct{}{}
+	bif.Monitor2(who, bif.Self())
+}
+
+// release stops monitoring who once it is not registered, a member of a group, nor watching.
+func (r *Registry) release(bif actors.Runtime, who actors.Pid) {
+	if _, registered := r.names[who]; registered {
+		return
+	}
+	if _, member := r.memberOf[who]; member {
+		return
+	}
+	if _, watching := r.watchers[who]; watching {
+		return
+	}
+	if _, has := r.monitoring[who]; has {
+		delete(r.monitoring, who)
+		bif.Unmonitor(who, bif.Self())
+	}
+}
+
+// exited removes the names, memberships and subscription of who.
+func (r *Registry) exited(bif actors.Runtime, who actors.Pid) {
+	delete(r.monitoring, who)
+	delete(r.watchers, who)
+	for group := range r.memberOf[who] {
+		r.removeMember(group, who)
+	}
+	delete(r.memberOf, who)
+	names := make([]string, 0, len(r.names[who]))
+	for name := range r.names[who] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	delete(r.names, who)
+	for _, name := range names {
+		delete(r.named, name)
+		r.notify(bif, Unregistered{Name: name, Who: who, Exited: true})
+	}
+}
+
+func (r *Registry) join(bif actors.Runtime, msg Join) {
+	if _, has := r.memberOf[msg.Who]; !has {
+		r.memberOf[msg.Who] = make(map[string]struct{})
+	}
+	if _, member := r.memberOf[msg.Who][msg.Group]; member {
+		return
+	}
+	r.memberOf[msg.Who][msg.Group] = struct{}{}
+	r.groups[msg.Group] = append(r.groups[msg.Group], msg.Who)
+	r.monitor(bif, msg.Who)
+}
+
+func (r *Registry) leave(bif actors.Runtime, group string, who actors.Pid) {
+	if _, member := r.memberOf[who][group]; !member {
+		return
+	}
+	r.removeMember(group, who)
+	delete(r.memberOf[who], group)
+	if len(r.memberOf[who]) == 0 {
+		delete(r.memberOf, who)
+		r.release(bif, who)
+	}
+}
+
+// removeMember removes who from the members of group, discarding the group once empty.
+func (r *Registry) removeMember(group string, who actors.Pid) {
+	members := slices.DeleteFunc(r.groups[group], func(member actors.Pid) bool { return member == who })
+	if len(members) == 0 {
+		delete(r.groups, group)
+	} else {
+		r.groups[group] = members
+	}
+}
+
+func (r *Registry) query(bif actors.Runtime, msg Query) {
+	if msg.Pattern != "" {
+		if _, err := path.Match(msg.Pattern, ""); err != nil {
+			bif.Log().Warn("Invalid query pattern %q: %s", msg.Pattern, err.Error())
+			bif.Tell(msg.Tell, QueryResult{})
+			return
+		}
+	}
+	var matches []LookupResult
+	for name, who := range r.named {
+		if !strings.HasPrefix(name, msg.Prefix) {
+			continue
+		}
+		if msg.Pattern != "" {
+			if matched, _ := path.Match(msg.Pattern, name); !matched {
+				continue
+			}
+		}
+		matches = append(matches, LookupResult{Found: true, Name: name, Who: who})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Name < matches[j].Name
+	})
+	bif.Tell(msg.Tell, QueryResult{Matches: matches})
+}
+
 func (r *Registry) lookup(bif actors.Runtime, msg Lookup) {
 	who, exists := r.named[msg.Name]
 	//TODO: client can cause a crash...perhaps there is a way to tell it to ignore problems?
@@ -73,7 +271,7 @@ type lookupProxy struct {
 func (l *lookupProxy) OnMessage(bif actors.Runtime, m any) {
 	switch m.(type) {
 	case *actors.Start:
-	case Lookup:
+	case Lookup, Members, Query:
 		bif.Tell(l.registry, m)
 	case actors.RpcAction[Queryable, LookupResult]:
 		bif.Tell(l.registry, m)
@@ -87,10 +285,11 @@ type SpawnedRegistry struct {
 	Query   actors.Pid
 }
 
+// SpawnRegistry spawns a registry and query proxy linked to the calling actor.  The caller shares the fate of the
+// registry: a failing registry terminates the caller unless it traps exits, receiving an actors.LinkExit instead.
 func SpawnRegistry(bif actors.Runtime) SpawnedRegistry {
-	//TODO: these should really be SpawnLink type things.
-	registry := bif.SpawnMonitor(NewRegistry())
-	proxy := bif.SpawnMonitor(&lookupProxy{registry: registry})
+	registry := bif.SpawnLink(NewRegistry())
+	proxy := bif.SpawnLink(&lookupProxy{registry: registry})
 	return SpawnedRegistry{
 		Control: registry,
 		Query:   proxy,
@@ -0,0 +1,91 @@
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/meschbach/go-junk-bucket/pkg/actors/testkit"
+	"github.com/stretchr/testify/assert"
+)
+
+// service exits upon receiving "exit" and panics upon "panic".
+type service struct{}
+
+func (s *service) OnMessage(r actors.Runtime, m any) {
+	switch m {
+	case "exit":
+		r.Exit(nil)
+	case "panic":
+		panic(m)
+	}
+}
+
+type registryHarness struct {
+	sys      *testkit.System
+	probe    *testkit.TestProbe
+	registry actors.Pid
+}
+
+func newRegistryHarness(t *testing.T) *registryHarness {
+	sys := testkit.NewSystem()
+	probe := testkit.NewTestProbe(t, sys)
+	registry := sys.Spawn(context.Background(), NewRegistry())
+	return &registryHarness{sys: sys, probe: probe, registry: registry}
+}
+
+func (h *registryHarness) spawn() actors.Pid {
+	return h.sys.Spawn(context.Background(), &service{})
+}
+
+func (h *registryHarness) lookup(name string) LookupResult {
+	h.probe.Tell(h.registry, Lookup{Tell: h.probe.Pid(), Name: name})
+	return testkit.ExpectMsgType[LookupResult](h.probe)
+}
+
+func TestAutoUnregistration(t *testing.T) {
+	t.Parallel()
+	for _, reason := range []string{"exit", "panic"} {
+		t.Run(reason, func(t *testing.T) {
+			t.Parallel()
+			h := newRegistryHarness(t)
+			who := h.spawn()
+			h.probe.Tell(h.registry, Register{Name: "service", Who: who})
+			h.probe.Tell(h.registry, Register{Name: "alias", Who: who})
+			assert.Equal(t, LookupResult{Found: true, Name: "service", Who: who}, h.lookup("service"))
+
+			h.probe.Tell(who, reason)
+			h.sys.RunUntilIdle()
+			assert.False(t, h.lookup("service").Found)
+			assert.False(t, h.lookup("alias").Found)
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+	h := newRegistryHarness(t)
+	existing := h.spawn()
+	h.probe.Tell(h.registry, Register{Name: "existing", Who: existing})
+	h.probe.Tell(h.registry, Watch{Observer: h.probe.Pid()})
+	h.probe.ExpectMsg(Registered{Name: "existing", Who: existing})
+
+	first := h.spawn()
+	h.probe.Tell(h.registry, Register{Name: "service", Who: first})
+	h.probe.ExpectMsg(Registered{Name: "service", Who: first})
+
+	second := h.spawn()
+	h.probe.Tell(h.registry, Register{Name: "service", Who: second})
+	h.probe.ExpectMsg(Replaced{Name: "service", Previous: first, Who: second})
+
+	h.probe.Tell(second, "exit")
+	h.probe.ExpectMsg(Unregistered{Name: "service", Who: second, Exited: true})
+
+	h.probe.Tell(h.registry, Unregister{Name: "existing", Who: existing})
+	h.probe.ExpectMsg(Unregistered{Name: "existing", Who: existing})
+
+	h.probe.Tell(first, "exit")
+	h.probe.Tell(h.registry, Unwatch{Observer: h.probe.Pid()})
+	h.probe.Tell(h.registry, Register{Name: "unobserved", Who: existing})
+	h.probe.ExpectNoMsg(0)
+}
@@ -80,3 +80,33 @@ type LookupRPC struct {
 func (l *LookupRPC) Invoke(bif actors.Runtime, state Queryable) LookupResult {
 	return state.Lookup(bif, l.Name)
 }
+
+// Watch subscribes observer to the Registered, Unregistered and Replaced events of the registry.
+func (c *ControllingClient) Watch(observer actors.Pid) {
+	c.bif.Tell(c.control, Watch{Observer: observer})
+}
+
+// Join adds who to group.
+func (c *ControllingClient) Join(group string, who actors.Pid) {
+	c.bif.Tell(c.control, Join{Group: group, Who: who})
+}
+
+// Leave removes who from group.
+func (c *ControllingClient) Leave(group string, who actors.Pid) {
+	c.bif.Tell(c.control, Leave{Group: group, Who: who})
+}
+
+// Broadcast delivers m to every member of group.
+func (c *ControllingClient) Broadcast(group string, m any) {
+	c.bif.Tell(c.control, Broadcast{Group: group, Message: m})
+}
+
+// Members requests the members of group, delivered to tell as GroupMembers.
+func (c *QueryClient) Members(group string, tell actors.Pid) {
+	c.bif.Tell(c.query, Members{Tell: tell, Group: group})
+}
+
+// Query requests the names matching prefix and pattern, delivered to tell as QueryResult.
+func (c *QueryClient) Query(prefix string, pattern string, tell actors.Pid) {
+	c.bif.Tell(c.query, Query{Tell: tell, Prefix: prefix, Pattern: pattern})
+}
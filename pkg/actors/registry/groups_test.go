@@ -0,0 +1,60 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/meschbach/go-junk-bucket/pkg/actors/testkit"
+	"github.com/stretchr/testify/assert"
+)
+
+func (h *registryHarness) members(group string) []actors.Pid {
+	h.probe.Tell(h.registry, Members{Tell: h.probe.Pid(), Group: group})
+	return testkit.ExpectMsgType[GroupMembers](h.probe).Members
+}
+
+func (h *registryHarness) query(prefix, pattern string) []string {
+	h.probe.Tell(h.registry, Query{Tell: h.probe.Pid(), Prefix: prefix, Pattern: pattern})
+	var names []string
+	for _, match := range testkit.ExpectMsgType[QueryResult](h.probe).Matches {
+		names = append(names, match.Name)
+	}
+	return names
+}
+
+func TestGroups(t *testing.T) {
+	t.Parallel()
+	h := newRegistryHarness(t)
+	first, second := h.spawn(), h.spawn()
+	h.probe.Tell(h.registry, Join{Group: "workers", Who: first})
+	h.probe.Tell(h.registry, Join{Group: "workers", Who: second})
+	h.probe.Tell(h.registry, Join{Group: "workers", Who: first})
+	h.probe.Tell(h.registry, Join{Group: "others", Who: first})
+	assert.Equal(t, []actors.Pid{first, second}, h.members("workers"))
+	assert.Empty(t, h.members("missing"))
+
+	h.probe.Tell(h.registry, Join{Group: "listeners", Who: h.probe.Pid()})
+	h.probe.Tell(h.registry, Broadcast{Group: "listeners", Message: "hello"})
+	h.probe.ExpectMsg("hello")
+
+	h.probe.Tell(h.registry, Leave{Group: "workers", Who: second})
+	assert.Equal(t, []actors.Pid{first}, h.members("workers"))
+
+	h.probe.Tell(first, "panic")
+	h.sys.RunUntilIdle()
+	assert.Empty(t, h.members("workers"))
+	assert.Empty(t, h.members("others"))
+}
+
+func TestQuery(t *testing.T) {
+	t.Parallel()
+	h := newRegistryHarness(t)
+	for _, name := range []string{"workers/b", "workers/a", "workers/a/nested", "services/db"} {
+		h.probe.Tell(h.registry, Register{Name: name, Who: h.spawn()})
+	}
+
+	assert.Equal(t, []string{"workers/a", "workers/a/nested", "workers/b"}, h.query("workers/", ""))
+	assert.Equal(t, []string{"workers/a", "workers/b"}, h.query("", "workers/*"))
+	assert.Equal(t, []string{"workers/a/nested"}, h.query("workers/a", "*/*/*"))
+	assert.Empty(t, h.query("", "["))
+}
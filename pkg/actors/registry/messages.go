@@ -22,3 +22,79 @@ type LookupResult struct {
 	Name  string
 	Who   actors.Pid
 }
+
+// Watch subscribes Observer to changes in the registry.  The observer first receives Registered for each name already
+// registered, followed by Registered, Unregistered and Replaced as the registry changes.
+type Watch struct {
+	Observer actors.Pid
+}
+
+// Unwatch stops delivering changes to Observer.
+type Unwatch struct {
+	Observer actors.Pid
+}
+
+// Registered notifies watchers a name has been bound to Who.
+type Registered struct {
+	Name string
+	Who  actors.Pid
+}
+
+// Unregistered notifies watchers a name is no longer bound to Who.
+type Unregistered struct {
+	Name string
+	Who  actors.Pid
+	//Exited is true when the name was removed because Who exited
+	Exited bool
+}
+
+// Replaced notifies watchers a name has been rebound from Previous to Who.
+type Replaced struct {
+	Name     string
+	Previous actors.Pid
+	Who      actors.Pid
+}
+
+// Join adds Who to Group, creating the group as needed.  A process may belong to many groups.
+type Join struct {
+	Group string
+	Who   actors.Pid
+}
+
+// Leave removes Who from Group.
+type Leave struct {
+	Group string
+	Who   actors.Pid
+}
+
+// Members requests the members of Group, replied to Tell as GroupMembers.
+type Members struct {
+	Tell  actors.Pid
+	Group string
+}
+
+// GroupMembers lists the members of a group in the order they joined.  An empty group has no members.
+type GroupMembers struct {
+	Group   string
+	Members []actors.Pid
+}
+
+// Broadcast delivers Message to every member of Group.
+type Broadcast struct {
+	Group   string
+	Message any
+}
+
+// Query requests the registered names beginning with Prefix and, when Pattern is not empty, matching the glob
+// Pattern as interpreted by path.Match.  For example a Pattern of "workers/*" matches "workers/a" but not
+// "workers/a/b".  Replied to Tell as QueryResult.
+type Query struct {
+	Tell    actors.Pid
+	Prefix  string
+	Pattern string
+}
+
+// QueryResult lists the registered names matching a Query, ordered by name.
+type QueryResult struct {
+	Matches []LookupResult
+}
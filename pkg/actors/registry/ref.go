@@ -7,7 +7,14 @@ type NamedRef struct {
 	query actors.Pid
 }
 
+// Resolve finds the process currently bound to the name.  As names are removed when their process exits, callers may
+// re-resolve after a restart, such as upon the Replaced or Registered events delivered to watchers.
 func (n NamedRef) Resolve(bif actors.Runtime) actors.Pid {
 	c := NewQueryClient(bif, n.query)
 	return c.Find(n.name)
 }
+
+// ResolveRef resolves the name to a Ref for actors accepting messages of type M.
+func ResolveRef[M any](bif actors.Runtime, n NamedRef) actors.Ref[M] {
+	return actors.RefOf[M](n.Resolve(bif))
+}
@@ -0,0 +1,163 @@
+package routing
+
+import (
+	"cmp"
+	"hash/fnv"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+)
+
+// Logic selects which routees of a pool receive each message.  Each pool requires its own Logic as implementations
+// may retain state about the routees.
+type Logic interface {
+	//Routees informs the logic of the routees of the pool.  Invoked before any message is routed and whenever the pool
+	//is resized.
+	Routees(routees []actors.Pid)
+	//Select chooses the routees to receive m.  Only invoked while the pool has routees.
+	Select(r actors.Runtime, m any) []actors.Pid
+}
+
+// RoundRobin delivers each message to the next routee in turn.
+func RoundRobin() Logic {
+	return &roundRobin{}
+}
+
+type roundRobin struct {
+	routees []actors.Pid
+	next    int
+}
+
+func (l *roundRobin) Routees(routees []actors.Pid) {
+	l.routees = routees
+	l.next = l.next % max(len(routees), 1)
+}
+
+func (l *roundRobin) Select(r actors.Runtime, m any) []actors.Pid {
+	target := l.routees[l.next]
+	l.next = (l.next + 1) % len(l.routees)
+	return []actors.Pid{target}
+}
+
+// Random delivers each message to a routee chosen uniformly at random.
+func Random() Logic {
+	return &random{}
+}
+
+type random struct {
+	routees []actors.Pid
+}
+
+func (l *random) Routees(routees []actors.Pid) {
+	l.routees = routees
+}
+
+func (l *random) Select(r actors.Runtime, m any) []actors.Pid {
+	return []actors.Pid{l.routees[rand.IntN(len(l.routees))]}
+}
+
+// Broadcast delivers each message to all routees.
+func Broadcast() Logic {
+	return &broadcast{}
+}
+
+type broadcast struct {
+	routees []actors.Pid
+}
+
+func (l *broadcast) Routees(routees []actors.Pid) {
+	l.routees = routees
+}
+
+func (l *broadcast) Select(r actors.Runtime, m any) []actors.Pid {
+	return l.routees
+}
+
+// SmallestMailbox delivers each message to the routee with the fewest messages waiting, preferring the earliest
+// spawned routee when tied.
+func SmallestMailbox() Logic {
+	return &smallestMailbox{}
+}
+
+type smallestMailbox struct {
+	routees []actors.Pid
+}
+
+func (l *smallestMailbox) Routees(routees []actors.Pid) {
+	l.routees = routees
+}
+
+func (l *smallestMailbox) Select(r actors.Runtime, m any) []actors.Pid {
+	target, smallest := l.routees[0], -1
+	for _, routee := range l.routees {
+		depth := r.MailboxDepth(routee)
+		if depth < 0 {
+			continue
+		}
+		if smallest < 0 || depth < smallest {
+			target, smallest = routee, depth
+		}
+		if smallest == 0 {
+			break
+		}
+	}
+	return []actors.Pid{target}
+}
+
+// DefaultVirtualNodes is the number of positions each routee occupies on the hash ring when not otherwise specified.
+const DefaultVirtualNodes = 100
+
+// ConsistentHash delivers messages with the same key to the same routee.  Each routee slot occupies virtualNodes
+// positions on a hash ring, so resizing the pool only moves the keys between the affected routees and a routee replaced
+// within its slot takes over the keys of the routee it replaced.  A virtualNodes of zero or less uses
+// DefaultVirtualNodes.
+func ConsistentHash(key func(m any) string, virtualNodes int) Logic {
+	if virtualNodes <= 0 {
+		virtualNodes = DefaultVirtualNodes
+	}
+	return &consistentHash{key: key, virtualNodes: virtualNodes}
+}
+
+type ringNode struct {
+	hash   uint64
+	routee actors.Pid
+}
+
+type consistentHash struct {
+	key          func(m any) string
+	virtualNodes int
+	//ring is sorted by hash
+	ring []ringNode
+}
+
+func hashOf(value string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(value))
+	return h.Sum64()
+}
+
+func (l *consistentHash) Routees(routees []actors.Pid) {
+	l.ring = make([]ringNode, 0, len(routees)*l.virtualNodes)
+	//positions are keyed by slot rather than Pid so replacements keep the range of the routee they replace
+	for slot, routee := range routees {
+		for index := range l.virtualNodes {
+			l.ring = append(l.ring, ringNode{hash: hashOf(strconv.Itoa(slot) + "#" + strconv.Itoa(index)), routee: routee})
+		}
+	}
+	slices.SortFunc(l.ring, func(a, b ringNode) int {
+		return cmp.Compare(a.hash, b.hash)
+	})
+}
+
+func (l *consistentHash) Select(r actors.Runtime, m any) []actors.Pid {
+	hash := hashOf(l.key(m))
+	index, _ := slices.BinarySearchFunc(l.ring, hash, func(node ringNode, target uint64) int {
+		return cmp.Compare(node.hash, target)
+	})
+	if index == len(l.ring) {
+		index = 0
+	}
+	return []actors.Pid{l.ring[index].routee}
+}
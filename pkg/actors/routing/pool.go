@@ -0,0 +1,134 @@
+package routing
+
+import (
+	"slices"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+)
+
+// PoolSpec describes a pool of identical routees sharing the messages sent to the pool.
+type PoolSpec struct {
+	//Size is the number of routees started with the pool
+	Size int
+	//Routee creates the actor for each routee
+	Routee func() actors.MessageActor
+	//Logic selects the routees for each message
+	Logic Logic
+	//Lower and Upper bound the size of the pool when adjusted via AdjustPoolSize.  An Upper of zero prevents the pool
+	//from being resized.
+	Lower int
+	Upper int
+}
+
+// AdjustPoolSize grows the pool by Change routees when positive or shrinks it when negative, within the bounds of the
+// PoolSpec.  Removed routees are terminated.
+type AdjustPoolSize struct {
+	Change int
+}
+
+// GetRoutees requests the current routees of a pool, replied to ReplyTo as Routees.
+type GetRoutees struct {
+	ReplyTo actors.Pid
+}
+
+// Routees lists the routees of a pool in the order they were spawned.
+type Routees struct {
+	Pids []actors.Pid
+}
+
+// routeeMomento identifies the exit notices of the pool's routees.
+type routeeMomento struct{}
+
+// NewPool creates a router which distributes all messages, other than management messages, between routees created
+// as children of the router.  Routees which exit are replaced to maintain the size of the pool.
+func NewPool(spec PoolSpec) actors.MessageActor {
+	return &pool{spec: spec, retired: make(map[actors.Pid]struct{})}
+}
+
+type pool struct {
+	spec    PoolSpec
+	routees []actors.Pid
+	//retired are routees removed from the pool whose exit should be ignored
+	retired map[actors.Pid]struct{}
+}
+
+func (p *pool) OnMessage(r actors.Runtime, m any) {
+	switch msg := m.(type) {
+	case *actors.Start:
+		p.grow(r, p.spec.Size)
+		p.spec.Logic.Routees(p.routees)
+	case actors.Stopping:
+	case AdjustPoolSize:
+		p.adjust(r, msg.Change)
+	case GetRoutees:
+		r.Tell(msg.ReplyTo, Routees{Pids: slices.Clone(p.routees)})
+	case actors.NormalExit:
+		if _, ok := msg.Momento.(routeeMomento); !ok {
+			p.route(r, m)
+			return
+		}
+		//routees stopped by a system shutdown are not replaced
+		_, stopping := msg.ExitValue.(actors.Stopping)
+		p.onRouteeExit(r, msg.Who, !stopping)
+	case actors.PanicExit:
+		if _, ok := msg.Momento.(routeeMomento); !ok {
+			p.route(r, m)
+			return
+		}
+		p.onRouteeExit(r, msg.Who, true)
+	default:
+		p.route(r, m)
+	}
+}
+
+func (p *pool) route(r actors.Runtime, m any) {
+	if len(p.routees) == 0 {
+		r.Log().Warn("no routees for %#v", m)
+		return
+	}
+	for _, target := range p.spec.Logic.Select(r, m) {
+		r.Tell(target, m)
+	}
+}
+
+func (p *pool) grow(r actors.Runtime, count int) {
+	for range count {
+		p.routees = append(p.routees, r.Spawn(p.spec.Routee(), actors.MonitorOpt{Tell: r.Self(), Momento: routeeMomento{}}))
+	}
+}
+
+// adjust resizes the pool by change, bounded by the spec.  Routees are removed most recently spawned first.
+func (p *pool) adjust(r actors.Runtime, change int) {
+	if p.spec.Upper <= 0 {
+		r.Log().Warn("pool is not resizable, ignoring adjustment by %d", change)
+		return
+	}
+	size := min(max(len(p.routees)+change, p.spec.Lower, 1), p.spec.Upper)
+	if size > len(p.routees) {
+		p.grow(r, size-len(p.routees))
+	}
+	for len(p.routees) > size {
+		last := p.routees[len(p.routees)-1]
+		p.routees = p.routees[:len(p.routees)-1]
+		p.retired[last] = struct{}{}
+		r.Terminate(last)
+	}
+	p.spec.Logic.Routees(p.routees)
+}
+
+func (p *pool) onRouteeExit(r actors.Runtime, who actors.Pid, replace bool) {
+	if _, retired := p.retired[who]; retired {
+		delete(p.retired, who)
+		return
+	}
+	index := slices.Index(p.routees, who)
+	if index < 0 {
+		return
+	}
+	if replace {
+		p.routees[index] = r.Spawn(p.spec.Routee(), actors.MonitorOpt{Tell: r.Self(), Momento: routeeMomento{}})
+	} else {
+		p.routees = slices.Delete(p.routees, index, index+1)
+	}
+	p.spec.Logic.Routees(p.routees)
+}
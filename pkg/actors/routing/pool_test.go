@@ -0,0 +1,231 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/meschbach/go-junk-bucket/pkg/actors/testkit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// delivery reports which routee received a message.
+type delivery struct {
+	routee  actors.Pid
+	message any
+}
+
+// reporter forwards each message to target as a delivery, panicking upon "panic".
+type reporter struct {
+	target actors.Pid
+}
+
+func (r *reporter) OnMessage(rt actors.Runtime, m any) {
+	switch msg := m.(type) {
+	case string:
+		if msg == "panic" {
+			panic(m)
+		}
+	case entityMessage:
+		if msg.body == "panic" {
+			panic(m)
+		}
+	}
+	switch m.(type) {
+	case *actors.Start, actors.Stopping:
+	default:
+		rt.Tell(r.target, delivery{routee: rt.Self(), message: m})
+	}
+}
+
+type poolHarness struct {
+	t     *testing.T
+	sys   *testkit.System
+	probe *testkit.TestProbe
+	pool  actors.Pid
+}
+
+func newPoolHarness(t *testing.T, spec PoolSpec) *poolHarness {
+	sys := testkit.NewSystem()
+	probe := testkit.NewTestProbe(t, sys)
+	spec.Routee = func() actors.MessageActor {
+		return &reporter{target: probe.Pid()}
+	}
+	pool := sys.Spawn(context.Background(), NewPool(spec))
+	return &poolHarness{t: t, sys: sys, probe: probe, pool: pool}
+}
+
+func (h *poolHarness) routees() []actors.Pid {
+	h.probe.Tell(h.pool, GetRoutees{ReplyTo: h.probe.Pid()})
+	return testkit.ExpectMsgType[Routees](h.probe).Pids
+}
+
+// receivers sends each message to the pool, returning the routee receiving each.
+func (h *poolHarness) receivers(messages ...any) []actors.Pid {
+	for _, m := range messages {
+		h.probe.Tell(h.pool, m)
+	}
+	out := make([]actors.Pid, len(messages))
+	for index, m := range messages {
+		d := testkit.ExpectMsgType[delivery](h.probe)
+		require.Equal(h.t, m, d.message)
+		out[index] = d.routee
+	}
+	return out
+}
+
+func TestRoundRobin(t *testing.T) {
+	t.Parallel()
+	h := newPoolHarness(t, PoolSpec{Size: 3, Logic: RoundRobin()})
+	routees := h.routees()
+	require.Len(t, routees, 3)
+
+	assert.Equal(t, append(routees, routees...), h.receivers(1, 2, 3, 4, 5, 6))
+}
+
+func TestRandom(t *testing.T) {
+	t.Parallel()
+	h := newPoolHarness(t, PoolSpec{Size: 3, Logic: Random()})
+	routees := h.routees()
+	for _, receiver := range h.receivers(1, 2, 3, 4, 5, 6) {
+		assert.Contains(t, routees, receiver)
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	t.Parallel()
+	h := newPoolHarness(t, PoolSpec{Size: 3, Logic: Broadcast()})
+	routees := h.routees()
+
+	h.probe.Tell(h.pool, "all")
+	var received []actors.Pid
+	for range routees {
+		d := testkit.ExpectMsgType[delivery](h.probe)
+		assert.Equal(t, "all", d.message)
+		received = append(received, d.routee)
+	}
+	assert.ElementsMatch(t, routees, received)
+}
+
+func TestConsistentHash(t *testing.T) {
+	t.Parallel()
+	keyOf := func(m any) string {
+		return fmt.Sprint(m.(int) % 10)
+	}
+	h := newPoolHarness(t, PoolSpec{Size: 4, Logic: ConsistentHash(keyOf, 0), Upper: 8})
+
+	messages := make([]any, 20)
+	for index := range messages {
+		messages[index] = index
+	}
+	receivers := h.receivers(messages...)
+	for index := range 10 {
+		assert.Equal(t, receivers[index], receivers[index+10], "key %d", index)
+	}
+
+	h.probe.Tell(h.pool, AdjustPoolSize{Change: 1})
+	added := h.routees()[4]
+	for index, receiver := range h.receivers(messages[:10]...) {
+		if receiver != added {
+			assert.Equal(t, receivers[index], receiver, "key %d moved between existing routees", index)
+		}
+	}
+}
+
+func TestConsistentHashReplacement(t *testing.T) {
+	t.Parallel()
+	keyOf := func(m any) string {
+		return fmt.Sprint(m.(int))
+	}
+	h := newPoolHarness(t, PoolSpec{Size: 4, Logic: ConsistentHash(keyOf, 0)})
+	routees := h.routees()
+	messages := make([]any, 20)
+	for index := range messages {
+		messages[index] = index
+	}
+	receivers := h.receivers(messages...)
+
+	failed := receivers[0]
+	slot := slices.Index(routees, failed)
+	h.probe.Tell(failed, "panic")
+	h.probe.Watch(failed)
+	h.probe.ExpectTerminated(failed)
+	replacement := h.routees()[slot]
+	require.NotEqual(t, failed, replacement)
+	for index, receiver := range h.receivers(messages...) {
+		expected := receivers[index]
+		if expected == failed {
+			expected = replacement
+		}
+		assert.Equal(t, expected, receiver, "key %d", index)
+	}
+}
+
+func TestSmallestMailbox(t *testing.T) {
+	t.Parallel()
+	h := newPoolHarness(t, PoolSpec{Size: 3, Logic: SmallestMailbox()})
+	routees := h.routees()
+
+	//the backlog of the first routee is queued before the pool routes any message
+	h.probe.Tell(h.pool, "a")
+	h.probe.Tell(h.pool, "b")
+	h.probe.Tell(h.pool, "c")
+	h.probe.Tell(routees[0], "backlog")
+	h.probe.Tell(routees[0], "backlog")
+	h.sys.RunUntilIdle()
+
+	received := make(map[any]actors.Pid)
+	for range 5 {
+		d := testkit.ExpectMsgType[delivery](h.probe)
+		received[d.message] = d.routee
+	}
+	assert.Equal(t, routees[1], received["a"])
+	assert.Equal(t, routees[2], received["b"])
+	assert.Equal(t, routees[1], received["c"], "ties prefer the earliest routee")
+}
+
+func TestAdjustPoolSize(t *testing.T) {
+	t.Parallel()
+	t.Run("within bounds", func(t *testing.T) {
+		t.Parallel()
+		h := newPoolHarness(t, PoolSpec{Size: 2, Logic: RoundRobin(), Lower: 1, Upper: 4})
+		initial := h.routees()
+
+		h.probe.Tell(h.pool, AdjustPoolSize{Change: 5})
+		grown := h.routees()
+		assert.Len(t, grown, 4)
+		assert.Equal(t, initial, grown[:2])
+
+		h.probe.Tell(h.pool, AdjustPoolSize{Change: -10})
+		shrunk := h.routees()
+		assert.Equal(t, initial[:1], shrunk)
+		h.probe.Watch(grown[3])
+		h.probe.ExpectTerminated(grown[3])
+		assert.Equal(t, shrunk, h.routees(), "retired routees are not replaced")
+	})
+
+	t.Run("fixed", func(t *testing.T) {
+		t.Parallel()
+		h := newPoolHarness(t, PoolSpec{Size: 2, Logic: RoundRobin()})
+		initial := h.routees()
+		h.probe.Tell(h.pool, AdjustPoolSize{Change: 1})
+		assert.Equal(t, initial, h.routees())
+	})
+}
+
+func TestRouteeReplacement(t *testing.T) {
+	t.Parallel()
+	h := newPoolHarness(t, PoolSpec{Size: 2, Logic: RoundRobin()})
+	initial := h.routees()
+
+	h.probe.Tell(initial[0], "panic")
+	h.probe.Watch(initial[0])
+	h.probe.ExpectTerminated(initial[0])
+	replaced := h.routees()
+	require.Len(t, replaced, 2)
+	assert.NotEqual(t, initial[0], replaced[0])
+	assert.Equal(t, initial[1], replaced[1])
+}
@@ -1,21 +1,126 @@
 package routing
 
-import "github.com/meschbach/go-junk-bucket/pkg/actors"
+import (
+	"fmt"
+	"time"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+)
+
+// ExitPolicy determines how a router treats a key after its routee exits.
+type ExitPolicy uint8
+
+const (
+	//RespawnOnMessage evicts the routee, spawning a new routee upon the next message for the key
+	RespawnOnMessage ExitPolicy = iota
+	//DropAfterExit evicts the routee and discards later messages for the key until no message for the key has arrived
+	//for the drop retention, after which the key is forgotten and spawned again upon the next message
+	DropAfterExit
+)
+
+// DefaultDropRetention is how long keys are dropped under DropAfterExit when no RetainDropsOpt is given.
+const DefaultDropRetention = 5 * time.Minute
+
+// PassivateOpt stops routees which have not been routed a message for Idle.  Passivated routees are spawned again
+// upon the next message for their key regardless of the ExitPolicy.
+type PassivateOpt struct {
+	Idle time.Duration
+}
+
+// RetainDropsOpt sets how long a key is dropped under DropAfterExit after the last message for the key.
+type RetainDropsOpt struct {
+	For time.Duration
+}
+
+// ClockOpt provides the time used to measure the activity of keys, defaulting to time.Now.  Allows virtual clocks such
+// as that of testkit.System to drive passivation.
+type ClockOpt struct {
+	Now func() time.Time
+}
+
+// routee is a routee spawned for a key.
+type routee[K any] struct {
+	key K
+	pid actors.Pid
+	//idle is the pending passivation of the routee
+	idle actors.TimerRef
+	//lastActive is when a message was last routed to the routee
+	lastActive time.Time
+}
+
+// passivate is scheduled to stop a routee once it has been idle.
+type passivate struct {
+	pid actors.Pid
+}
+
+// forgetDropped is scheduled to forget a dropped key once no messages have arrived for it.
+type forgetDropped struct {
+	key any
+}
 
 type router[M any, K any] struct {
 	//TOOD: generic map
-	routees map[any]actors.Pid
-	bridge  Bridge[M, K]
+	routees map[any]*routee[K]
+	//byPid resolves the routees by their Pid to handle exits
+	byPid map[actors.Pid]*routee[K]
+	//dropped are keys whose routee exited under DropAfterExit, with the time of the last message for the key
+	dropped map[any]time.Time
+	//passivated are routees stopped by the router whose exit should be ignored
+	passivated map[actors.Pid]struct{}
+	bridge     Bridge[M, K]
+	onExit     ExitPolicy
+	idle       time.Duration
+	retainDrop time.Duration
+	now        func() time.Time
 }
 
-func NewRouter[M any, K any](bridge Bridge[M, K]) actors.MessageActor {
-	return &router[M, K]{
-		routees: make(map[any]actors.Pid),
-		bridge:  bridge,
+// NewRouter creates an actor routing each message of type M to the routee for the message's key, spawning the routee
+// upon the first message for the key.  Routees are monitored and evicted upon exit.  Accepts an ExitPolicy, a
+// PassivateOpt, a RetainDropsOpt and a ClockOpt as options.
+func NewRouter[M any, K any](bridge Bridge[M, K], opts ...any) actors.MessageActor {
+	r := &router[M, K]{
+		routees:    make(map[any]*routee[K]),
+		byPid:      make(map[actors.Pid]*routee[K]),
+		dropped:    make(map[any]time.Time),
+		passivated: make(map[actors.Pid]struct{}),
+		bridge:     bridge,
+		retainDrop: DefaultDropRetention,
+		now:        time.Now,
 	}
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case ExitPolicy:
+			r.onExit = o
+		case PassivateOpt:
+			r.idle = o.Idle
+		case RetainDropsOpt:
+			r.retainDrop = o.For
+		case ClockOpt:
+			r.now = o.Now
+		default:
+			panic(fmt.Sprintf("unknown option type %#v", opt))
+		}
+	}
+	return r
 }
 
 func (r *router[M, K]) OnMessage(bif actors.Runtime, m any) {
+	switch msg := m.(type) {
+	case actors.NormalExit:
+		if r.onRouteeExit(bif, msg.Who) {
+			return
+		}
+	case actors.PanicExit:
+		if r.onRouteeExit(bif, msg.Who) {
+			return
+		}
+	case passivate:
+		r.passivate(bif, msg)
+		return
+	case forgetDropped:
+		r.forgetDropped(bif, msg)
+		return
+	}
 	switch msg := m.(type) {
 	case M:
 		r.route(bif, msg)
@@ -24,11 +129,78 @@ func (r *router[M, K]) OnMessage(bif actors.Runtime, m any) {
 
 func (r *router[M, K]) route(bif actors.Runtime, m M) {
 	key := r.bridge.Extract(m)
-	if target, ok := r.routees[key]; ok {
-		bif.Tell(target, m)
-	} else {
-		spawned := r.bridge.SpawnRoutee(bif, key, m)
-		r.routees[key] = spawned
-		bif.Tell(spawned, m)
+	if _, dropped := r.dropped[key]; dropped {
+		r.dropped[key] = r.now()
+		bif.Log().Warn("dropping message for exited routee %v", key)
+		return
+	}
+	target, ok := r.routees[key]
+	if !ok {
+		pid := r.bridge.SpawnRoutee(bif, key, m)
+		bif.Monitor2(pid, bif.Self())
+		target = &routee[K]{key: key, pid: pid}
+		r.routees[key] = target
+		r.byPid[pid] = target
+		if r.idle > 0 {
+			target.idle = bif.SendAfter(r.idle, passivate{pid: pid})
+		}
+	}
+	target.lastActive = r.now()
+	bif.Tell(target.pid, m)
+}
+
+// onRouteeExit evicts the exited routee, returning false if who is not a routee.
+func (r *router[M, K]) onRouteeExit(bif actors.Runtime, who actors.Pid) bool {
+	if _, passivated := r.passivated[who]; passivated {
+		delete(r.passivated, who)
+		return true
+	}
+	target, ok := r.byPid[who]
+	if !ok {
+		return false
+	}
+	r.evict(target)
+	if r.onExit == DropAfterExit {
+		r.dropped[target.key] = r.now()
+		bif.SendAfter(r.retainDrop, forgetDropped{key: target.key})
+	}
+	return true
+}
+
+func (r *router[M, K]) evict(target *routee[K]) {
+	delete(r.byPid, target.pid)
+	delete(r.routees, target.key)
+	if target.idle != nil {
+		target.idle.Cancel()
+	}
+}
+
+// passivate stops the routee once no messages have been routed to it for the idle duration.  Each routee has a single
+// passivation pending, rescheduled for the remainder of the idle duration while the routee is active.
+func (r *router[M, K]) passivate(bif actors.Runtime, p passivate) {
+	target, ok := r.byPid[p.pid]
+	if !ok {
+		return
+	}
+	if idle := r.now().Sub(target.lastActive); idle < r.idle {
+		target.idle = bif.SendAfter(r.idle-idle, p)
+		return
+	}
+	r.evict(target)
+	r.passivated[p.pid] = struct{}{}
+	bif.Terminate(p.pid)
+}
+
+// forgetDropped forgets the dropped key once no messages for it have arrived for the drop retention, rescheduling
+// itself for the remainder otherwise.
+func (r *router[M, K]) forgetDropped(bif actors.Runtime, f forgetDropped) {
+	last, ok := r.dropped[f.key]
+	if !ok {
+		return
+	}
+	if quiet := r.now().Sub(last); quiet < r.retainDrop {
+		bif.SendAfter(r.retainDrop-quiet, f)
+		return
 	}
+	delete(r.dropped, f.key)
 }
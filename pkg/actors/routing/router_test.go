@@ -0,0 +1,112 @@
+package routing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/meschbach/go-junk-bucket/pkg/actors/testkit"
+	"github.com/stretchr/testify/assert"
+)
+
+// entityMessage is routed to the entity named by key.
+type entityMessage struct {
+	key  string
+	body string
+}
+
+// entityBridge spawns a reporter for each key.
+type entityBridge struct {
+	target actors.Pid
+}
+
+func (e *entityBridge) Extract(msg entityMessage) string {
+	return msg.key
+}
+
+func (e *entityBridge) SpawnRoutee(bif actors.Runtime, key string, msg entityMessage) actors.Pid {
+	return bif.Spawn(&reporter{target: e.target})
+}
+
+func newRouterHarness(t *testing.T, opts ...any) *poolHarness {
+	sys := testkit.NewSystem()
+	probe := testkit.NewTestProbe(t, sys)
+	opts = append(opts, ClockOpt{Now: sys.Now})
+	router := sys.Spawn(context.Background(), NewRouter[entityMessage, string](&entityBridge{target: probe.Pid()}, opts...))
+	return &poolHarness{t: t, sys: sys, probe: probe, pool: router}
+}
+
+func TestRouterEviction(t *testing.T) {
+	t.Parallel()
+	t.Run("respawn on message", func(t *testing.T) {
+		t.Parallel()
+		h := newRouterHarness(t)
+		first := h.receivers(entityMessage{key: "a", body: "1"}, entityMessage{key: "a", body: "2"})
+		assert.Equal(t, first[0], first[1])
+
+		h.probe.Tell(h.pool, entityMessage{key: "a", body: "panic"})
+		h.probe.Watch(first[0])
+		h.probe.ExpectTerminated(first[0])
+
+		second := h.receivers(entityMessage{key: "a", body: "3"})
+		assert.NotEqual(t, first[0], second[0])
+		assert.Empty(t, h.sys.DeadLetters())
+	})
+
+	t.Run("drop after exit", func(t *testing.T) {
+		t.Parallel()
+		h := newRouterHarness(t, DropAfterExit)
+		first := h.receivers(entityMessage{key: "a", body: "1"})
+		h.probe.Tell(h.pool, entityMessage{key: "a", body: "panic"})
+		h.probe.Watch(first[0])
+		h.probe.ExpectTerminated(first[0])
+
+		h.probe.Tell(h.pool, entityMessage{key: "a", body: "2"})
+		h.probe.ExpectNoMsg(time.Second)
+		assert.Empty(t, h.sys.DeadLetters())
+		assert.NotEqual(t, first[0], h.receivers(entityMessage{key: "b", body: "3"})[0])
+	})
+
+	t.Run("dropped keys are forgotten once quiet", func(t *testing.T) {
+		t.Parallel()
+		h := newRouterHarness(t, DropAfterExit, RetainDropsOpt{For: time.Minute})
+		first := h.receivers(entityMessage{key: "a", body: "1"})
+		h.probe.Tell(h.pool, entityMessage{key: "a", body: "panic"})
+		h.probe.Watch(first[0])
+		h.probe.ExpectTerminated(first[0])
+
+		h.sys.Advance(30 * time.Second)
+		h.probe.Tell(h.pool, entityMessage{key: "a", body: "2"})
+		h.sys.Advance(59 * time.Second)
+		h.probe.Tell(h.pool, entityMessage{key: "a", body: "3"})
+		h.probe.ExpectNoMsg(time.Second)
+
+		h.sys.Advance(time.Minute)
+		second := h.receivers(entityMessage{key: "a", body: "4"})
+		assert.NotEqual(t, first[0], second[0])
+	})
+}
+
+func TestRouterPassivation(t *testing.T) {
+	t.Parallel()
+	h := newRouterHarness(t, PassivateOpt{Idle: time.Minute})
+	busy := h.receivers(entityMessage{key: "busy", body: "1"})[0]
+	idle := h.receivers(entityMessage{key: "idle", body: "1"})[0]
+	h.probe.Watch(busy)
+	h.probe.Watch(idle)
+
+	h.sys.Advance(30 * time.Second)
+	assert.Equal(t, []actors.Pid{busy}, h.receivers(entityMessage{key: "busy", body: "tick"}))
+	h.sys.Advance(30 * time.Second)
+	h.probe.ExpectTerminated(idle)
+	assert.Equal(t, []actors.Pid{busy}, h.receivers(entityMessage{key: "busy", body: "tick"}))
+	h.sys.Advance(59 * time.Second)
+	assert.Equal(t, []actors.Pid{busy}, h.receivers(entityMessage{key: "busy", body: "tick"}))
+
+	h.sys.Advance(time.Minute)
+	h.probe.ExpectTerminated(busy)
+
+	respawned := h.receivers(entityMessage{key: "idle", body: "2"})[0]
+	assert.NotEqual(t, idle, respawned)
+}
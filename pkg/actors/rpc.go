@@ -1,34 +1,190 @@
 package actors
 
-import "time"
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"time"
 
+	"github.com/meschbach/go-junk-bucket/pkg/task"
+)
+
+var lastCorrelation atomic.Uint64
+
+// NextCorrelation produces a process unique identifier for matching replies with requests.
+func NextCorrelation() uint64 {
+	return lastCorrelation.Add(1)
+}
+
+// Reply is a response to a Call or Ask, tagged with the correlation of the request.
+type Reply struct {
+	Correlation uint64
+	Value       any
+}
+
+// ReplyTo describes where to send the response for an Ask.
+type ReplyTo struct {
+	Pid         Pid
+	Correlation uint64
+}
+
+// Reply sends value as the response to the request.
+func (r ReplyTo) Reply(from Ingestor, value any) {
+	from.Tell(r.Pid, Reply{Correlation: r.Correlation, Value: value})
+}
+
+// CallTimeoutError indicates the context expired before the target replied.
+type CallTimeoutError struct {
+	Target Pid
+	Cause  error
+}
+
+func (c *CallTimeoutError) Error() string {
+	return fmt.Sprintf("timed out waiting for %s: %s", c.Target, c.Cause)
+}
+
+func (c *CallTimeoutError) Unwrap() error {
+	return c.Cause
+}
+
+// TargetExitedError indicates the target exited before replying.
+type TargetExitedError struct {
+	Target Pid
+	//Panicked is true if the target exited abnormally
+	Panicked bool
+	//ExitValue is the value the target exited with, or NoProcess if the target did not exist
+	ExitValue any
+}
+
+func (t *TargetExitedError) Error() string {
+	if t.Panicked {
+		return fmt.Sprintf("target %s panicked before replying", t.Target)
+	}
+	return fmt.Sprintf("target %s exited with %#v before replying", t.Target, t.ExitValue)
+}
+
+// WrongReplyError indicates the target replied with a value of an unexpected type.
+type WrongReplyError struct {
+	Target   Pid
+	Expected reflect.Type
+	Reply    any
+}
+
+func (w *WrongReplyError) Error() string {
+	return fmt.Sprintf("target %s replied with %T, expected %s", w.Target, w.Reply, w.Expected)
+}
+
+// CallService performs action against target, waiting up to 100ms for the result.  Failures are fatal to the calling
+// actor.
 func CallService[S any, R any](bif Runtime, target Pid, action RpcAction[S, R]) R {
-	mailbox := bif.SpawnMailbox()
-	p := mailbox.Pid()
-	bif.Monitor2(target, p)
-	bif.Tell(target, RpcCall[S, R]{
-		tell:   p,
-		action: action,
-	})
-	result, problem := mailbox.ReceiveTimeout(100 * time.Millisecond)
+	ctx, done := context.WithTimeout(bif.Context(), 100*time.Millisecond)
+	defer done()
+	result, problem := Call[S, R](ctx, bif, target, action)
 	if problem != nil {
 		bif.Log().Fatal("RPC failure %s", problem.Error())
 	}
-	if out, ok := result.(R); ok {
-		return out
-	} else {
-		panic(result)
+	return result
+}
+
+// Call performs action against target, blocking the calling actor until the target replies, the target exits, or ctx
+// is done.
+func Call[S any, R any](ctx context.Context, bif Runtime, target Pid, action RpcAction[S, R]) (R, error) {
+	return Ask[R](ctx, bif, target, func(replyTo ReplyTo) any {
+		return RpcCall[S, R]{
+			tell:        replyTo.Pid,
+			correlation: replyTo.Correlation,
+			action:      action,
+		}
+	})
+}
+
+// Ask sends the message built by request to target, blocking the calling actor until the target replies via the given
+// ReplyTo, the target exits, or ctx is done.
+func Ask[R any](ctx context.Context, bif Runtime, target Pid, request func(replyTo ReplyTo) any) (R, error) {
+	mailbox, replyTo := sendAsk(bif, target, request)
+	defer mailbox.Close(ctx)
+	defer bif.Unmonitor(target, mailbox.Pid())
+	return awaitReply[R](ctx, mailbox, target, replyTo.Correlation)
+}
+
+// AskAsync sends the message built by request to target without blocking the calling actor.  The promise is resolved
+// within a later tick of the calling actor, so handlers may safely access the actor's state.  The wait outlives the
+// current tick, so ctx is detached from the cancellation of Runtime.Context while keeping any deadline of ctx.
+func AskAsync[R any](ctx context.Context, bif Runtime, target Pid, request func(replyTo ReplyTo) any) *task.Promise[R] {
+	promise := &task.Promise[R]{}
+	mailbox, replyTo := sendAsk(bif, target, request)
+	waiting, done := detach(ctx)
+	go func() {
+		defer done()
+		result, problem := awaitReply[R](waiting, mailbox, target, replyTo.Correlation)
+		mailbox.Close(waiting)
+		bif.Defer(func(r Runtime) {
+			r.Unmonitor(target, mailbox.Pid())
+			if problem != nil {
+				promise.Failure(r.Context(), problem)
+			} else {
+				promise.Success(r.Context(), result)
+			}
+		})
+	}()
+	return promise
+}
+
+// detach provides a context which is not cancelled with ctx, retaining the deadline of ctx if present.
+func detach(ctx context.Context) (context.Context, context.CancelFunc) {
+	detached := context.WithoutCancel(ctx)
+	if deadline, has := ctx.Deadline(); has {
+		return context.WithDeadline(detached, deadline)
+	}
+	return context.WithCancel(detached)
+}
+
+func sendAsk(bif Runtime, target Pid, request func(replyTo ReplyTo) any) (Port, ReplyTo) {
+	mailbox := bif.SpawnMailbox()
+	replyTo := ReplyTo{Pid: mailbox.Pid(), Correlation: NextCorrelation()}
+	bif.Monitor2(target, replyTo.Pid)
+	bif.Tell(target, request(replyTo))
+	return mailbox, replyTo
+}
+
+func awaitReply[R any](ctx context.Context, mailbox Port, target Pid, correlation uint64) (R, error) {
+	var zero R
+	for {
+		msg, problem := mailbox.ReceiveWith(ctx)
+		if problem != nil {
+			return zero, &CallTimeoutError{Target: target, Cause: problem}
+		}
+		switch m := msg.(type) {
+		case Reply:
+			if m.Correlation != correlation {
+				continue
+			}
+			if out, ok := m.Value.(R); ok {
+				return out, nil
+			}
+			return zero, &WrongReplyError{Target: target, Expected: reflect.TypeFor[R](), Reply: m.Value}
+		case NormalExit:
+			if m.Who == target {
+				return zero, &TargetExitedError{Target: target, ExitValue: m.ExitValue}
+			}
+		case PanicExit:
+			if m.Who == target {
+				return zero, &TargetExitedError{Target: target, Panicked: true}
+			}
+		}
 	}
 }
 
 type RpcCall[S any, R any] struct {
-	tell   Pid
-	action RpcAction[S, R]
+	tell        Pid
+	correlation uint64
+	action      RpcAction[S, R]
 }
 
 func (r *RpcCall[S, R]) Perform(bif Runtime, state S) {
 	result := r.action.Invoke(bif, state)
-	bif.Tell(r.tell, result)
+	bif.Tell(r.tell, Reply{Correlation: r.correlation, Value: result})
 }
 
 type RpcAction[S any, R any] interface {
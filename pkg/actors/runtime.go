@@ -1,6 +1,9 @@
 package actors
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Runtime represents the world from teh point of view of an actor.  Actors must use this interface to work within the
 // actor system.
@@ -23,6 +26,16 @@ type Runtime interface {
 	//diff between mailbox and port: port is intended for external commms, mailbox is meant for rpc like comms
 	SpawnMailbox() Port
 
+	//SpawnLink spawns a new actor linked to the executing actor
+	SpawnLink(actor MessageActor, opts ...any) Pid
+	//Link establishes a bidirectional link between the executing actor and other.  When either exits abnormally the
+	//other exits as well unless trapping exits.
+	Link(other Pid)
+	//Unlink removes the link between the executing actor and other
+	Unlink(other Pid)
+	//TrapExits controls if exits of linked actors are delivered as LinkExit messages instead of terminating the actor
+	TrapExits(trap bool)
+
 	//Monitor2 allows external monitoring between two processes
 	Monitor2(watching Pid, watcher Pid)
 	//Unmonitor will remove the {watched,watcher} pairs.
@@ -31,12 +44,40 @@ type Runtime interface {
 	Terminate(target Pid)
 	Exit(result any)
 
+	//SendAfter delivers m to the executing actor through its mailbox once delay has elapsed.  Outstanding timers are
+	//cancelled when the actor exits.
+	SendAfter(delay time.Duration, m any) TimerRef
+	//SendInterval delivers m to the executing actor through its mailbox every period until cancelled
+	SendInterval(period time.Duration, m any) TimerRef
+
 	Register(name string, who Pid)
 	Unregister(name string)
 	LookupPath(path string) Pid
 	NamedRef(name string) string
 	SelfNamedRef() string
 
+	//Become replaces the handler for subsequent user messages, discarding the current handler.  The actor itself is
+	//never discarded, so a state machine may Become repeatedly and Unbecome back to the actor.
+	Become(handler MessageActor)
+	//BecomeStacked places handler over the current handler for subsequent user messages, retaining the current handler
+	//for Unbecome.
+	BecomeStacked(handler MessageActor)
+	//Unbecome restores the handler beneath the current handler
+	Unbecome()
+	//Stash defers m until UnstashAll is called.  Returns a StashFullError once the stash is at capacity.  The stash is
+	//discarded when the actor exits.
+	Stash(m any) error
+	//UnstashAll places all stashed messages, in the order they were stashed, ahead of messages waiting in the mailbox
+	UnstashAll()
+
+	//MailboxDepth is the number of messages waiting to be handled by who, or -1 if who is not a living local actor or
+	//port.  The result is immediately stale, so it is only suitable for heuristics such as load balancing.
+	MailboxDepth(who Pid) int
+
+	//Defer runs fn within a later tick of the executing actor, preserving single threaded access to the actor's state.
+	//Safe to call from any goroutine.
+	Defer(fn func(r Runtime))
+
 	//Context is the context of the currently invoking tick
 	Context() context.Context
 }
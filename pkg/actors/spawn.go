@@ -1,5 +1,7 @@
 package actors
 
+import "fmt"
+
 type MonitorOpt struct {
 	Tell    Pid
 	Momento any
@@ -8,3 +10,79 @@ type MonitorOpt struct {
 type RegisterOpt struct {
 	Name string
 }
+
+// LinkOpt links the spawned actor with the given actor before the spawned actor starts.
+type LinkOpt struct {
+	With Pid
+}
+
+// StashOpt sets the maximum number of messages the actor may stash.
+type StashOpt struct {
+	Capacity int
+}
+
+// StashFullError is returned by Runtime.Stash when the stash is at capacity.
+type StashFullError struct {
+	Capacity int
+}
+
+func (s *StashFullError) Error() string {
+	return fmt.Sprintf("stash is full (capacity %d)", s.Capacity)
+}
+
+// OverflowPolicy determines how a mailbox reacts to messages arriving while it is at capacity.
+type OverflowPolicy uint8
+
+const (
+	//OverflowBlock blocks the sender until the actor has consumed a message.  Node links and timers are never blocked,
+	//dead lettering the message instead
+	OverflowBlock OverflowPolicy = iota
+	//OverflowDropNewest discards the arriving message
+	OverflowDropNewest
+	//OverflowDropOldest discards the oldest queued message to make room for the arriving message
+	OverflowDropOldest
+	//OverflowFail panics within the sending actor or port with a MailboxFullError.  Messages from elsewhere are dead
+	//lettered
+	OverflowFail
+	//OverflowUnbounded ignores the capacity, growing the mailbox as needed
+	OverflowUnbounded
+)
+
+func (o OverflowPolicy) String() string {
+	switch o {
+	case OverflowBlock:
+		return "block"
+	case OverflowDropNewest:
+		return "drop-newest"
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowFail:
+		return "fail"
+	case OverflowUnbounded:
+		return "unbounded"
+	default:
+		return fmt.Sprintf("OverflowPolicy(%d)", uint8(o))
+	}
+}
+
+// MailboxOpt sets the number of user messages which may be queued for the actor and the policy applied once the
+// mailbox is full.  System signals, such as monitoring and termination, are not subject to the capacity.  Instead they
+// wait within a separate lane, handled ahead of any user messages.  Exits delivered as messages, NormalExit and the
+// LinkExit of actors trapping exits, are queued behind user messages so they never overtake messages sent before the
+// exit.
+type MailboxOpt struct {
+	Capacity int
+	Overflow OverflowPolicy
+	//SystemCapacity is the number of system signals which may be queued before their senders block, defaulting to 64
+	SystemCapacity int
+}
+
+// MailboxFullError is raised within the sender when telling an actor with a full mailbox using OverflowFail.
+type MailboxFullError struct {
+	Target   Pid
+	Capacity int
+}
+
+func (m *MailboxFullError) Error() string {
+	return fmt.Sprintf("mailbox of %s is full (capacity %d)", m.Target, m.Capacity)
+}
@@ -1,11 +1,45 @@
 package supervisor
 
 import (
+	"sort"
+	"time"
+
 	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 )
 
+// MeterName is the instrumentation scope of the metrics recorded by supervisors.
+const MeterName = "git.meschbach.com/mee/junk/actors/supervisor"
+
+// newRestartCounter creates the counter of restarted children through the provider of spec.  The actor.path attribute
+// is the named path of the restarted child, matching the metrics of the actor system.
+func newRestartCounter(spec Spec) metric.Int64Counter {
+	provider := spec.MeterProvider
+	if provider == nil {
+		provider = otel.GetMeterProvider()
+	}
+	counter, err := provider.Meter(MeterName).Int64Counter("actors.supervisor.restarts", metric.WithDescription("Children restarted by a supervisor"))
+	if err != nil {
+		otel.Handle(err)
+	}
+	return counter
+}
+
 type childState struct {
-	pid actors.Pid
+	pid   actors.Pid
+	spec  ChildSpec
+	index int
+	//started is when the child was last started
+	started time.Time
+	//failures is the number of consecutive restarts, used to compute the backoff
+	failures int
+}
+
+// restartDue is scheduled by the supervisor to restart children once their backoff has elapsed.
+type restartDue struct {
+	children []*childState
 }
 
 type supervisorStatus uint8
@@ -18,17 +52,39 @@ const (
 
 type actor struct {
 	controller Behavior
+	spec       Spec
 	children   map[string]*childState
 	status     supervisorStatus
 	listeners  []actors.Pid
+	//terminating are the children stopped as part of a restart which have yet to exit
+	terminating map[string]struct{}
+	//restarting are the children to start once all terminating children have exited
+	restarting []*childState
+	//restarts are the times of recent restarts, used to enforce the restart intensity
+	restarts []time.Time
+	//retained are the children stopped via TerminateChild which have not been deleted
+	retained map[string]*childState
+	//stopped are children intentionally stopped whose exit should be ignored
+	stopped map[actors.Pid]struct{}
+	//nextIndex orders children, allowing RestForOne to restart those started after a child
+	nextIndex int
+	//workers counts the children started from the SimpleOneForOne template
+	workers int
+	//delay is the backoff to apply before restarting the pending children
+	delay time.Duration
+	//restartCount records each restarted child
+	restartCount metric.Int64Counter
 }
 
 func newActor(controller Behavior) *actor {
 	return &actor{
-		controller: controller,
-		children:   make(map[string]*childState),
-		status:     supervisorInit,
-		listeners:  make([]actors.Pid, 0),
+		controller:  controller,
+		children:    make(map[string]*childState),
+		status:      supervisorInit,
+		listeners:   make([]actors.Pid, 0),
+		terminating: make(map[string]struct{}),
+		retained:    make(map[string]*childState),
+		stopped:     make(map[actors.Pid]struct{}),
 	}
 }
 
@@ -39,78 +95,215 @@ func (a *actor) OnMessage(r actors.Runtime, m any) {
 	case actors.Start:
 		a.start(r)
 	case actors.PanicExit:
-		a.onPanic(r, msg)
+		id := msg.Momento.(string)
+		r.Log().Warn("actor %s (%s) panicked.", id, msg.Who)
+		a.onChildExit(r, id, msg.Who, true)
 	case actors.NormalExit:
-		a.onExit(r, msg)
+		//children stopped by a system shutdown are not restarted
+		if _, stopping := msg.ExitValue.(actors.Stopping); stopping {
+			a.forget(r, msg.Momento.(string), msg.Who)
+			return
+		}
+		a.onChildExit(r, msg.Momento.(string), msg.Who, false)
 	case WatchState:
 		a.listeners = append(a.listeners, msg.Observer)
+	case StartChildRequest:
+		a.onStartChild(r, msg)
+	case TerminateChild:
+		a.onTerminateChild(r, msg)
+	case DeleteChild:
+		a.onDeleteChild(r, msg)
+	case restartDue:
+		a.restart(r, msg.children)
+	case actors.Stopping:
 	default:
 		r.Log().Warn("unexpected message %#v", m)
 	}
 }
 
 func (a *actor) start(r actors.Runtime) {
-	self := r.Self()
-	spec := a.controller.Init(r)
-	a.status = supervisorAlive
-
-	for _, c := range spec.Children {
-		id := c.Id
-		if _, has := a.children[id]; has {
-			r.Log().Fatal("supervisor ID conflict: %s", id)
+	a.spec = a.controller.Init(r)
+	a.restartCount = newRestartCounter(a.spec)
+	if a.spec.Strategy == SimpleOneForOne {
+		if len(a.spec.Children) != 1 {
+			r.Log().Fatal("simple_one_for_one requires exactly one child template, got %d", len(a.spec.Children))
+		}
+		a.ready(r)
+		return
+	}
+	for _, c := range a.spec.Children {
+		if _, has := a.children[c.Id]; has {
+			r.Log().Fatal("supervisor ID conflict: %s", c.Id)
 		} else {
-			pid := r.Spawn(c.Start(), actors.MonitorOpt{Tell: self, Momento: c.Id})
-			r.Register(id, pid)
-			a.children[id] = &childState{pid: pid}
+			a.nextIndex++
+			a.startChild(r, a.nextIndex, c)
 		}
 	}
-	//notify listeners
+	a.ready(r)
+}
+
+func (a *actor) startChild(r actors.Runtime, index int, c ChildSpec) *childState {
+	pid := r.Spawn(c.Start(), actors.MonitorOpt{Tell: r.Self(), Momento: c.Id})
+	r.Register(c.Id, pid)
+	child := &childState{pid: pid, spec: c, index: index, started: time.Now()}
+	a.children[c.Id] = child
+	return child
+}
+
+// ready notifies listeners all children have been started.
+func (a *actor) ready(r actors.Runtime) {
+	a.status = supervisorAlive
 	for _, l := range a.listeners {
 		r.Tell(l, StateReady{})
 	}
 }
 
-func (a *actor) onPanic(r actors.Runtime, exit actors.PanicExit) {
-	id := exit.Momento.(string)
-	r.Log().Warn("actor %s (%s) panicked.", id, exit.Who)
-	if _, has := a.children[id]; has {
-		delete(a.children, id)
-		r.Unregister(id)
-		//restart all
-		a.terminateForRestart(r)
-	} else {
-		r.Log().Warn("received panic for %s (pid: %s) but unknown", exit.Who, id)
+// forget removes an exited child without restarting it, returning the child if it was known.
+func (a *actor) forget(r actors.Runtime, id string, who actors.Pid) (*childState, bool) {
+	if _, stopped := a.stopped[who]; stopped {
+		delete(a.stopped, who)
+		return nil, false
+	}
+	child, has := a.children[id]
+	if !has || child.pid != who {
+		r.Log().Warn("unknown id %q exited from %s", id, who)
+		return nil, false
 	}
+	delete(a.children, id)
+	r.Unregister(id)
+	if _, terminating := a.terminating[id]; terminating {
+		delete(a.terminating, id)
+		a.resume(r)
+		return nil, false
+	}
+	return child, true
 }
 
-func (a *actor) terminateForRestart(r actors.Runtime) {
-	a.status = supervisorTerminatingForRestart
-	if len(a.children) == 0 {
-		a.start(r)
-	} else {
-		for _, s := range a.children {
-			r.Terminate(s.pid)
+func (a *actor) onChildExit(r actors.Runtime, id string, who actors.Pid, panicked bool) {
+	child, has := a.forget(r, id, who)
+	if !has {
+		return
+	}
+	switch child.spec.Restart {
+	case Temporary:
+		return
+	case Transient:
+		if !panicked {
+			return
 		}
 	}
+
+	if !a.allowRestart() {
+		a.escalate(r)
+		return
+	}
+	backoff := a.spec.Backoff
+	if child.spec.Backoff != nil {
+		backoff = *child.spec.Backoff
+	}
+	if reset := backoff.resetAfter(); reset > 0 && time.Since(child.started) >= reset {
+		child.failures = 0
+	}
+	a.delay = max(a.delay, backoff.Delay(child.failures))
+	child.failures++
+	a.restarting = append(a.restarting, child)
+	switch a.spec.Strategy {
+	case OneForOne, SimpleOneForOne:
+	case OneForAll:
+		a.terminateWhere(r, func(other *childState) bool { return true })
+	case RestForOne:
+		a.terminateWhere(r, func(other *childState) bool { return other.index > child.index })
+	default:
+		r.Log().Fatal("unknown supervisor strategy %s", a.spec.Strategy)
+	}
+	a.resume(r)
+}
+
+// allowRestart records a restart, returning false if the restart intensity has been exceeded.
+func (a *actor) allowRestart() bool {
+	if a.spec.MaxRestarts <= 0 {
+		return true
+	}
+	now := time.Now()
+	recent := a.restarts[:0]
+	for _, at := range a.restarts {
+		if now.Sub(at) < a.spec.period() {
+			recent = append(recent, at)
+		}
+	}
+	a.restarts = append(recent, now)
+	return len(a.restarts) <= a.spec.MaxRestarts
+}
+
+// escalate terminates all children then exits abnormally so the supervisor's own supervisor may react.
+func (a *actor) escalate(r actors.Runtime) {
+	for _, c := range a.children {
+		a.stop(r, c)
+	}
+	panic(&RestartIntensityError{MaxRestarts: a.spec.MaxRestarts, Period: a.spec.period()})
+}
+
+// stop terminates the child, ignoring the resulting exit.
+func (a *actor) stop(r actors.Runtime, c *childState) {
+	delete(a.children, c.spec.Id)
+	r.Unregister(c.spec.Id)
+	a.stopped[c.pid] = struct{}{}
+	r.Terminate(c.pid)
+}
+
+// terminateWhere terminates the matching children for restart.  Temporary children are terminated but not restarted.
+func (a *actor) terminateWhere(r actors.Runtime, matches func(other *childState) bool) {
+	for id, c := range a.children {
+		if _, terminating := a.terminating[id]; terminating || !matches(c) {
+			continue
+		}
+		if c.spec.Restart == Temporary {
+			a.stop(r, c)
+			continue
+		}
+		a.terminating[id] = struct{}{}
+		a.restarting = append(a.restarting, c)
+		r.Terminate(c.pid)
+	}
 }
 
-func (a *actor) onExit(r actors.Runtime, msg actors.NormalExit) {
-	id := msg.Momento.(string)
-	if _, has := a.children[id]; has {
-		delete(a.children, id)
-		r.Unregister(id)
+// resume starts the children awaiting restart once all terminating children have exited.
+func (a *actor) resume(r actors.Runtime) {
+	if len(a.terminating) > 0 {
+		a.status = supervisorTerminatingForRestart
+		return
+	}
+	restarting, delay := a.restarting, a.delay
+	a.restarting, a.delay = nil, 0
+	if len(restarting) == 0 {
+		return
+	}
+	if delay > 0 {
+		ids := make([]string, len(restarting))
+		for index, c := range restarting {
+			ids[index] = c.spec.Id
+		}
+		for _, l := range a.listeners {
+			r.Tell(l, StateRestarting{Ids: ids, Delay: delay})
+		}
+		r.SendAfter(delay, restartDue{children: restarting})
+		return
+	}
+	a.restart(r, restarting)
+}
 
-		switch a.status {
-		case supervisorAlive:
-			a.terminateForRestart(r)
-		case supervisorTerminatingForRestart:
-			if len(a.children) == 0 {
-				a.start(r)
-			}
-		default:
-			r.Log().Fatal("unknown state for handling terminated actor: %d", a.status)
+// restart starts the children in the order they were originally started.  Children started via StartChildRequest while
+// awaiting the restart are left alone.
+func (a *actor) restart(r actors.Runtime, restarting []*childState) {
+	sort.Slice(restarting, func(i, j int) bool {
+		return restarting[i].index < restarting[j].index
+	})
+	for _, c := range restarting {
+		if a.known(c.spec.Id) {
+			continue
 		}
-	} else {
-		r.Log().Warn("unknown id %q exited from %s", id, msg.Who)
+		a.startChild(r, c.index, c.spec).failures = c.failures
+		a.restartCount.Add(r.Context(), 1, metric.WithAttributes(attribute.String("actor.path", r.NamedRef(c.spec.Id)), attribute.String("child", c.spec.Id)))
 	}
+	a.ready(r)
 }
@@ -0,0 +1,56 @@
+package supervisor
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// DefaultMaxBackoff caps the delay of a Backoff which does not specify Max.
+const DefaultMaxBackoff = time.Minute
+
+// Backoff delays the restart of a repeatedly failing child.  Each consecutive restart multiplies the delay, starting at
+// Initial and capped at Max.  The zero value restarts immediately.
+type Backoff struct {
+	Initial time.Duration
+	//Max caps each delay, including any jitter, defaulting to DefaultMaxBackoff
+	Max time.Duration
+	//Multiplier grows the delay for each consecutive restart, defaulting to 2
+	Multiplier float64
+	//Jitter randomly adjusts each delay by up to the given fraction, spreading out restarts of related children
+	Jitter float64
+	//ResetAfter is how long a child must run before prior failures are forgotten, defaulting to Max or DefaultMaxBackoff
+	ResetAfter time.Duration
+}
+
+// Delay is the time to wait before the restart following the given number of consecutive failures.
+func (b Backoff) Delay(failures int) time.Duration {
+	if b.Initial <= 0 {
+		return 0
+	}
+	multiplier := b.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	delay := float64(b.Initial) * math.Pow(multiplier, float64(failures))
+	if b.Jitter > 0 {
+		delay += delay * b.Jitter * (rand.Float64()*2 - 1)
+	}
+	//clamped while a float as the delay may exceed the range of a Duration after enough failures
+	delay = min(max(delay, 0), float64(b.max()))
+	return time.Duration(delay)
+}
+
+func (b Backoff) max() time.Duration {
+	if b.Max > 0 {
+		return b.Max
+	}
+	return DefaultMaxBackoff
+}
+
+func (b Backoff) resetAfter() time.Duration {
+	if b.ResetAfter > 0 {
+		return b.ResetAfter
+	}
+	return b.max()
+}
@@ -0,0 +1,54 @@
+package supervisor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Zero value restarts immediately", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, time.Duration(0), Backoff{}.Delay(3))
+	})
+
+	t.Run("Delays grow exponentially up to the maximum", func(t *testing.T) {
+		t.Parallel()
+		b := Backoff{Initial: 10 * time.Millisecond, Max: 50 * time.Millisecond}
+		assert.Equal(t, 10*time.Millisecond, b.Delay(0))
+		assert.Equal(t, 20*time.Millisecond, b.Delay(1))
+		assert.Equal(t, 40*time.Millisecond, b.Delay(2))
+		assert.Equal(t, 50*time.Millisecond, b.Delay(3))
+	})
+
+	t.Run("Delays never exceed the default maximum", func(t *testing.T) {
+		t.Parallel()
+		b := Backoff{Initial: time.Second}
+		assert.Equal(t, DefaultMaxBackoff, b.Delay(100))
+		assert.Equal(t, DefaultMaxBackoff, b.Delay(10000), "delays beyond the range of a Duration are capped")
+		assert.Equal(t, DefaultMaxBackoff, b.resetAfter())
+	})
+
+	t.Run("Jitter does not exceed the maximum", func(t *testing.T) {
+		t.Parallel()
+		b := Backoff{Initial: 100 * time.Millisecond, Max: 100 * time.Millisecond, Jitter: 0.5}
+		for i := 0; i < 100; i++ {
+			delay := b.Delay(1)
+			assert.GreaterOrEqual(t, delay, 90*time.Millisecond)
+			assert.LessOrEqual(t, delay, 100*time.Millisecond)
+		}
+	})
+
+	t.Run("Jitter stays within the fraction", func(t *testing.T) {
+		t.Parallel()
+		b := Backoff{Initial: 100 * time.Millisecond, Jitter: 0.1}
+		for i := 0; i < 100; i++ {
+			delay := b.Delay(0)
+			assert.GreaterOrEqual(t, delay, 90*time.Millisecond)
+			assert.LessOrEqual(t, delay, 110*time.Millisecond)
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package supervisor
+
+import (
+	"fmt"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+)
+
+// StartChildRequest adds a child to a running supervisor.  Under SimpleOneForOne only Spec.Id is used, with the remainder of
+// the specification taken from the template; an empty Id is generated from the template's Id.  Replies with a
+// ChildReply when ReplyTo is set.
+type StartChildRequest struct {
+	Spec    ChildSpec
+	ReplyTo actors.ReplyTo
+}
+
+// TerminateChild stops a child without restarting it.  The specification is retained until removed via DeleteChild,
+// except under SimpleOneForOne where it is discarded.  Replies with a ChildReply when ReplyTo is set.
+type TerminateChild struct {
+	Id      string
+	ReplyTo actors.ReplyTo
+}
+
+// DeleteChild discards the specification of a child stopped via TerminateChild.  Replies with a ChildReply when
+// ReplyTo is set.
+type DeleteChild struct {
+	Id      string
+	ReplyTo actors.ReplyTo
+}
+
+// ChildReply is the response to StartChildRequest, TerminateChild and DeleteChild.  Err is nil if the request succeeded.
+type ChildReply struct {
+	Id  string
+	Pid actors.Pid
+	Err error
+}
+
+// ChildExistsError indicates a child with the Id is already known to the supervisor.
+type ChildExistsError struct {
+	Id string
+}
+
+func (c *ChildExistsError) Error() string {
+	return fmt.Sprintf("child %q already exists", c.Id)
+}
+
+// NoSuchChildError indicates the supervisor has no child with the Id.
+type NoSuchChildError struct {
+	Id string
+}
+
+func (n *NoSuchChildError) Error() string {
+	return fmt.Sprintf("no such child %q", n.Id)
+}
+
+// ChildRunningError indicates a child must be terminated before it may be deleted.
+type ChildRunningError struct {
+	Id string
+}
+
+func (c *ChildRunningError) Error() string {
+	return fmt.Sprintf("child %q is running", c.Id)
+}
+
+func reply(r actors.Runtime, to actors.ReplyTo, result ChildReply) {
+	if to.Pid != (actors.Pid{}) {
+		to.Reply(r, result)
+	}
+}
+
+func (a *actor) onStartChild(r actors.Runtime, msg StartChildRequest) {
+	spec := msg.Spec
+	if a.spec.Strategy == SimpleOneForOne {
+		template := a.spec.Children[0]
+		id := spec.Id
+		if id == "" {
+			a.workers++
+			id = fmt.Sprintf("%s-%d", template.Id, a.workers)
+		}
+		spec = template
+		spec.Id = id
+	}
+	if a.known(spec.Id) {
+		reply(r, msg.ReplyTo, ChildReply{Id: spec.Id, Err: &ChildExistsError{Id: spec.Id}})
+		return
+	}
+	a.nextIndex++
+	child := a.startChild(r, a.nextIndex, spec)
+	reply(r, msg.ReplyTo, ChildReply{Id: spec.Id, Pid: child.pid})
+}
+
+func (a *actor) onTerminateChild(r actors.Runtime, msg TerminateChild) {
+	child, has := a.children[msg.Id]
+	if !has {
+		reply(r, msg.ReplyTo, ChildReply{Id: msg.Id, Err: &NoSuchChildError{Id: msg.Id}})
+		return
+	}
+	a.stop(r, child)
+	if a.spec.Strategy != SimpleOneForOne {
+		a.retained[msg.Id] = child
+	}
+	reply(r, msg.ReplyTo, ChildReply{Id: msg.Id, Pid: child.pid})
+}
+
+func (a *actor) onDeleteChild(r actors.Runtime, msg DeleteChild) {
+	if _, running := a.children[msg.Id]; running {
+		reply(r, msg.ReplyTo, ChildReply{Id: msg.Id, Err: &ChildRunningError{Id: msg.Id}})
+		return
+	}
+	if _, has := a.retained[msg.Id]; !has {
+		reply(r, msg.ReplyTo, ChildReply{Id: msg.Id, Err: &NoSuchChildError{Id: msg.Id}})
+		return
+	}
+	delete(a.retained, msg.Id)
+	reply(r, msg.ReplyTo, ChildReply{Id: msg.Id})
+}
+
+func (a *actor) known(id string) bool {
+	_, running := a.children[id]
+	_, retained := a.retained[id]
+	return running || retained
+}
@@ -1,6 +1,10 @@
 package supervisor
 
-import "github.com/meschbach/go-junk-bucket/pkg/actors"
+import (
+	"time"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+)
 
 type WatchState struct {
 	Observer actors.Pid
@@ -8,3 +12,9 @@ type WatchState struct {
 
 type StateReady struct {
 }
+
+// StateRestarting is sent to listeners when children will be restarted once Delay elapses.
+type StateRestarting struct {
+	Ids   []string
+	Delay time.Duration
+}
@@ -1,18 +1,111 @@
 package supervisor
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"go.opentelemetry.io/otel/metric"
 )
 
 type StartChild = func() actors.MessageActor
 
+// RestartType describes when a child is restarted after exiting.
+type RestartType uint8
+
+const (
+	//Permanent children are always restarted
+	Permanent RestartType = iota
+	//Transient children are restarted only when they panic
+	Transient
+	//Temporary children are never restarted
+	Temporary
+)
+
+func (r RestartType) String() string {
+	switch r {
+	case Permanent:
+		return "permanent"
+	case Transient:
+		return "transient"
+	case Temporary:
+		return "temporary"
+	default:
+		return fmt.Sprintf("RestartType(%d)", uint8(r))
+	}
+}
+
 type ChildSpec struct {
-	Id    string
-	Start StartChild
+	Id      string
+	Start   StartChild
+	Restart RestartType
+	//Backoff overrides the Spec's Backoff for this child when set
+	Backoff *Backoff
+}
+
+// Strategy describes which children are restarted when a child exits.
+type Strategy uint8
+
+const (
+	//OneForAll terminates and restarts all children when any child exits.  The children are started again from the
+	//ChildSpecs recorded when they were first started; Behavior.Init is not invoked again.
+	OneForAll Strategy = iota
+	//OneForOne restarts only the exited child
+	OneForOne
+	//RestForOne restarts the exited child and all children declared after it
+	RestForOne
+	//SimpleOneForOne starts no children on initialization.  The only ChildSpec is a template for the workers added via
+	//StartChildRequest, each restarted independently.
+	SimpleOneForOne
+)
+
+func (s Strategy) String() string {
+	switch s {
+	case OneForAll:
+		return "one_for_all"
+	case OneForOne:
+		return "one_for_one"
+	case RestForOne:
+		return "rest_for_one"
+	case SimpleOneForOne:
+		return "simple_one_for_one"
+	default:
+		return fmt.Sprintf("Strategy(%d)", uint8(s))
+	}
 }
 
+// DefaultRestartPeriod is the Period over which MaxRestarts is counted when the Spec does not specify one.
+const DefaultRestartPeriod = 5 * time.Second
+
 type Spec struct {
 	Children []ChildSpec
+	Strategy Strategy
+	//MaxRestarts is the number of restarts tolerated within Period.  Exceeding the limit causes the supervisor to exit
+	//abnormally, escalating to its own supervisor.  Zero places no limit on restarts.
+	MaxRestarts int
+	//Period is the window restarts are counted within, defaulting to DefaultRestartPeriod
+	Period time.Duration
+	//Backoff delays restarts of children failing repeatedly
+	Backoff Backoff
+	//MeterProvider records the restarts of children, defaulting to the global provider
+	MeterProvider metric.MeterProvider
+}
+
+func (s Spec) period() time.Duration {
+	if s.Period > 0 {
+		return s.Period
+	}
+	return DefaultRestartPeriod
+}
+
+// RestartIntensityError is the reason a supervisor exits when children restart more often than the Spec allows.
+type RestartIntensityError struct {
+	MaxRestarts int
+	Period      time.Duration
+}
+
+func (r *RestartIntensityError) Error() string {
+	return fmt.Sprintf("more than %d restarts within %s", r.MaxRestarts, r.Period)
 }
 
 // Behavior describes the children to be supervised and the resulting behaviors to exhibit in reaction to their state
@@ -22,6 +22,9 @@ type System interface {
 	//Spawn a new actor delegating to actor for user messages with the given option set
 	Spawn(ctx context.Context, actor MessageActor, opts ...any) Pid
 	Lookup(ctx context.Context, absolutePath string) Pid
+	//Shutdown stops all actors, children before their parents.  Each actor receives Stopping once its mailbox has
+	//drained.  Actors which have not stopped once ctx is done are forcibly closed and reported via a ShutdownError.
+	Shutdown(ctx context.Context) error
 }
 
 type Logger interface {
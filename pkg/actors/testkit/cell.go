@@ -0,0 +1,259 @@
+package testkit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+)
+
+const defaultStashCapacity = 128
+
+type monitor struct {
+	listener actors.Pid
+	momento  any
+}
+
+// cell is an actor within the System.  Relationships with other actors are guarded by the system's lock, while the
+// behaviors and stash are only accessed by the actor while handling a message.
+type cell struct {
+	system   *System
+	self     actors.Pid
+	parent   *cell
+	names    map[string]actors.Pid
+	monitors []monitor
+	links    map[actors.Pid]struct{}
+	children map[*cell]struct{}
+	timers   map[*timer]struct{}
+	//busy is set while the actor is handling a message
+	busy bool
+	done bool
+
+	trapExits     bool
+	behaviors     []actors.MessageActor
+	stash         []envelope
+	stashCapacity int
+}
+
+// Signals are produced by the system and handled by the cell instead of the actor.
+type (
+	exitSignal struct {
+		result any
+	}
+	terminateSignal struct{}
+	stopSignal      struct{}
+	linkExitSignal  struct {
+		from     actors.Pid
+		reason   any
+		abnormal bool
+	}
+	deferSignal struct {
+		fn func(r actors.Runtime)
+	}
+	timerSignal struct {
+		timer *timer
+	}
+)
+
+func isSignal(m any) bool {
+	switch m.(type) {
+	case exitSignal, terminateSignal, stopSignal, linkExitSignal, deferSignal, timerSignal:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *cell) handler() actors.MessageActor {
+	return c.behaviors[len(c.behaviors)-1]
+}
+
+func (c *cell) deliver(e envelope) {
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+	r := &runtime{cell: c, ctx: ctx, current: e}
+
+	defer func() {
+		if problem := recover(); problem != nil {
+			logger := &logger{who: c.self}
+			logger.Error("actor panic: %#v", problem)
+			c.exit(problem, true, func(m monitor) any {
+				return actors.NewPanicExit(c.self, m.momento)
+			})
+		}
+	}()
+
+	switch m := e.message.(type) {
+	case exitSignal:
+		c.exit(m.result, false, func(mon monitor) any {
+			return actors.NormalExit{Who: c.self, ExitValue: m.result, Momento: mon.momento}
+		})
+	case terminateSignal:
+		c.exit(actors.Terminated{}, true, func(mon monitor) any {
+			return actors.NormalExit{Who: c.self, ExitValue: actors.Terminated{}, Momento: mon.momento}
+		})
+	case stopSignal:
+		c.handler().OnMessage(r, actors.Stopping{})
+		c.exit(actors.Stopping{}, false, func(mon monitor) any {
+			return actors.NormalExit{Who: c.self, ExitValue: actors.Stopping{}, Momento: mon.momento}
+		})
+	case linkExitSignal:
+		c.onLinkExit(r, m)
+	case deferSignal:
+		m.fn(r)
+	case timerSignal:
+		if m.timer.claim() {
+			c.handler().OnMessage(r, m.timer.message)
+		}
+	default:
+		c.handler().OnMessage(r, e.message)
+	}
+}
+
+func (c *cell) onLinkExit(r *runtime, signal linkExitSignal) {
+	c.system.lock.Lock()
+	_, linked := c.links[signal.from]
+	delete(c.links, signal.from)
+	c.system.lock.Unlock()
+	if !linked {
+		return
+	}
+	switch {
+	case c.trapExits:
+		c.handler().OnMessage(r, actors.LinkExit{Who: signal.from, Reason: signal.reason, Abnormal: signal.abnormal})
+	case signal.abnormal:
+		c.exit(signal.reason, true, func(m monitor) any {
+			return actors.NewPanicExit(c.self, m.momento)
+		})
+	}
+}
+
+// exit stops the actor, informing monitors with the notification built by notice and linked actors with reason.
+// Messages waiting for the actor become dead letters.
+func (c *cell) exit(reason any, abnormal bool, notice func(m monitor) any) {
+	s := c.system
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if c.done {
+		return
+	}
+	c.done = true
+	delete(s.cells, c.self)
+	if c.parent != nil {
+		delete(c.parent.children, c)
+	}
+	for t := range c.timers {
+		t.cancelLocked()
+	}
+	remaining := s.queue[:0]
+	for _, e := range s.queue {
+		if e.to == c.self {
+			s.deadLetterLocked(e, actors.DeadLetterTargetDone)
+		} else {
+			remaining = append(remaining, e)
+		}
+	}
+	s.queue = remaining
+	for _, stashed := range c.stash {
+		s.deadLetterLocked(stashed, actors.DeadLetterTargetDone)
+	}
+	c.stash = nil
+
+	for _, m := range c.monitors {
+		s.tellLocked(c.self, m.listener, notice(m))
+	}
+	for linked := range c.links {
+		if other, has := s.cells[linked]; has {
+			s.enqueueLocked(c.self, other.self, linkExitSignal{from: c.self, reason: reason, abnormal: abnormal})
+		}
+	}
+	c.links = make(map[actors.Pid]struct{})
+}
+
+// linkLocked establishes a link between the cell and other, notifying the cell if other does not exist.
+func (c *cell) linkLocked(other actors.Pid) {
+	c.links[other] = struct{}{}
+	if target, has := c.system.cells[other]; has {
+		target.links[c.self] = struct{}{}
+		return
+	}
+	c.system.enqueueLocked(other, c.self, linkExitSignal{from: other, reason: actors.NoProcess{}, abnormal: true})
+}
+
+// namedParts is the path of names from the root actor to this actor.
+func (c *cell) namedParts() []string {
+	if c.parent == nil {
+		return []string{}
+	}
+	name := "<anonymous>"
+	for registered, pid := range c.parent.names {
+		if pid == c.self {
+			name = registered
+		}
+	}
+	return append(c.parent.namedParts(), name)
+}
+
+func (s *System) tellLocked(sender, to actors.Pid, m any) {
+	if p, has := s.ports[to]; has {
+		if !p.push(m) {
+			s.deadLetterLocked(envelope{to: to, sender: sender, message: m}, actors.DeadLetterPortClosed)
+		}
+		return
+	}
+	if _, has := s.cells[to]; has {
+		s.enqueueLocked(sender, to, m)
+		return
+	}
+	s.deadLetterLocked(envelope{to: to, sender: sender, message: m}, actors.DeadLetterNoTarget)
+}
+
+// logger writes to standard out, in the same form as the local system's console logger.
+type logger struct {
+	who   actors.Pid
+	attrs []any
+}
+
+func (l *logger) write(level string, format string, args []any) {
+	fmt.Printf("%s %s: "+format+"\n", append([]any{l.who.String(), level}, args...)...)
+}
+
+func (l *logger) Info(format string, args ...any) {
+	l.write("info", format, args)
+}
+
+func (l *logger) Warn(format string, args ...any) {
+	l.write("warn", format, args)
+}
+
+func (l *logger) Error(format string, args ...any) {
+	l.write("error", format, args)
+}
+
+func (l *logger) Fatal(format string, args ...any) {
+	panic(fmt.Sprintf(format, args...))
+}
+
+func (l *logger) Enabled(level slog.Level) bool {
+	return level >= slog.LevelInfo
+}
+
+func (l *logger) Log(level slog.Level, msg string, args ...any) {
+	if !l.Enabled(level) {
+		return
+	}
+	line := actors.FormatStructured(msg, append(append([]any{}, l.attrs...), args...)...)
+	switch {
+	case level >= slog.LevelError:
+		l.Error("%s", line)
+	case level >= slog.LevelWarn:
+		l.Warn("%s", line)
+	default:
+		l.Info("%s", line)
+	}
+}
+
+func (l *logger) With(args ...any) actors.StructuredLogger {
+	return &logger{who: l.who, attrs: append(append([]any{}, l.attrs...), args...)}
+}
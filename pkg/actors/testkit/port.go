@@ -0,0 +1,131 @@
+package testkit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+)
+
+// NoMessageError indicates a port did not receive a message before the system became idle, or before the virtual
+// clock advanced by Waited.
+type NoMessageError struct {
+	For    actors.Pid
+	Waited time.Duration
+}
+
+func (n *NoMessageError) Error() string {
+	return fmt.Sprintf("no message for %s after %s of virtual time", n.For, n.Waited)
+}
+
+// port receives messages from actors of the System.  Waiting on a port steps the system until a message arrives.
+type port struct {
+	system *System
+	self   actors.Pid
+	//lock guards the inbox and may be acquired while holding the system lock, never the reverse.
+	lock   sync.Mutex
+	inbox  []any
+	closed bool
+}
+
+func newPort(s *System, self actors.Pid) *port {
+	return &port{system: s, self: self}
+}
+
+// push places m within the inbox, returning false if the port has been closed.
+func (p *port) push(m any) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.closed {
+		return false
+	}
+	p.inbox = append(p.inbox, m)
+	return true
+}
+
+// pop removes the oldest message from the inbox.
+func (p *port) pop() (any, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if len(p.inbox) == 0 {
+		return nil, false
+	}
+	m := p.inbox[0]
+	p.inbox = p.inbox[1:]
+	return m, true
+}
+
+func (p *port) hasMessage() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return len(p.inbox) > 0
+}
+
+func (p *port) Pid() actors.Pid {
+	return p.self
+}
+
+// ReceiveChannel runs the system until idle then provides the messages received so far.
+func (p *port) ReceiveChannel() <-chan any {
+	p.system.RunUntilIdle()
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	out := make(chan any, len(p.inbox))
+	for _, m := range p.inbox {
+		out <- m
+	}
+	p.inbox = nil
+	return out
+}
+
+// Receive steps the system until a message arrives.  Panics if the system becomes idle first.
+func (p *port) Receive() any {
+	m, err := p.ReceiveWith(context.Background())
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// ReceiveTimeout steps the system, advancing the virtual clock by up to wait, until a message arrives.
+func (p *port) ReceiveTimeout(wait time.Duration) (any, error) {
+	if p.system.advanceUntil(p.system.Now().Add(wait), p.hasMessage) {
+		m, _ := p.pop()
+		return m, nil
+	}
+	return nil, &NoMessageError{For: p.self, Waited: wait}
+}
+
+// ReceiveWith steps the system until a message arrives without advancing the virtual clock.  Returns an error if the
+// system becomes idle or ctx is done first.
+func (p *port) ReceiveWith(ctx context.Context) (any, error) {
+	for {
+		if m, has := p.pop(); has {
+			return m, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !p.system.Step() {
+			return nil, &NoMessageError{For: p.self}
+		}
+	}
+}
+
+func (p *port) Tell(ctx context.Context, who actors.Pid, what any) {
+	p.system.tell(p.self, who, what)
+}
+
+func (p *port) Log(ctx context.Context) actors.Logger {
+	return &logger{who: p.self}
+}
+
+// Close stops the port from receiving messages.  Closed ports remain known to the system so further messages are
+// reported as dead letters with actors.DeadLetterPortClosed.
+func (p *port) Close(ctx context.Context) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.closed = true
+}
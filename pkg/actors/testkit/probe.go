@@ -0,0 +1,109 @@
+package testkit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/stretchr/testify/require"
+)
+
+// DefaultProbeTimeout is how long a TestProbe waits for an expected message.  With the deterministic System the wait
+// is measured on the virtual clock.
+const DefaultProbeTimeout = 3 * time.Second
+
+// TestProbe is a port with assertions on the messages it receives.  Works with any actors.System; with the
+// deterministic System waiting steps the system and advances the virtual clock instead of sleeping.
+type TestProbe struct {
+	t      testing.TB
+	system actors.System
+	port   actors.Port
+	//Timeout is how long to wait for expected messages
+	Timeout time.Duration
+}
+
+// NewTestProbe creates a probe receiving messages on a new port of sys.  The port is closed when the test completes.
+func NewTestProbe(t testing.TB, sys actors.System) *TestProbe {
+	port := sys.NewPort()
+	t.Cleanup(func() {
+		port.Close(context.Background())
+	})
+	return &TestProbe{t: t, system: sys, port: port, Timeout: DefaultProbeTimeout}
+}
+
+// Pid of the probe, for actors to send messages to.
+func (p *TestProbe) Pid() actors.Pid {
+	return p.port.Pid()
+}
+
+// Tell sends m to who with the probe as the sender.
+func (p *TestProbe) Tell(who actors.Pid, m any) {
+	p.port.Tell(context.Background(), who, m)
+}
+
+// Watch monitors who, delivering its exit notice to the probe.
+func (p *TestProbe) Watch(who actors.Pid) {
+	p.system.Spawn(context.Background(), &watcher{watched: who, listener: p.Pid()})
+}
+
+// receive fails the test if no message arrives within wait.
+func (p *TestProbe) receive(wait time.Duration) any {
+	p.t.Helper()
+	m, err := p.port.ReceiveTimeout(wait)
+	require.NoError(p.t, err, "expected a message")
+	return m
+}
+
+// ExpectMsg fails the test unless the next message equals expected, returning the message.
+func (p *TestProbe) ExpectMsg(expected any) any {
+	p.t.Helper()
+	m := p.receive(p.Timeout)
+	require.Equal(p.t, expected, m)
+	return m
+}
+
+// ExpectNoMsg fails the test if a message arrives within d.
+func (p *TestProbe) ExpectNoMsg(d time.Duration) {
+	p.t.Helper()
+	m, err := p.port.ReceiveTimeout(d)
+	require.Error(p.t, err, "expected no message, received %#v", m)
+}
+
+// ExpectTerminated fails the test unless the next message is the exit notice of who, as delivered to monitors.  The
+// probe must be monitoring who, such as through Watch.  Returns either the actors.NormalExit or actors.PanicExit.
+func (p *TestProbe) ExpectTerminated(who actors.Pid) any {
+	p.t.Helper()
+	m := p.receive(p.Timeout)
+	switch exit := m.(type) {
+	case actors.NormalExit:
+		require.Equal(p.t, who, exit.Who, "exit of another actor")
+	case actors.PanicExit:
+		require.Equal(p.t, who, exit.Who, "exit of another actor")
+	default:
+		require.Failf(p.t, "expected termination", "of %s, received %#v", who, m)
+	}
+	return m
+}
+
+// ExpectMsgType fails the test unless the next message received by p is of type M, returning the message.
+func ExpectMsgType[M any](p *TestProbe) M {
+	p.t.Helper()
+	m := p.receive(p.Timeout)
+	typed, ok := m.(M)
+	require.Truef(p.t, ok, "expected %T, received %#v", typed, m)
+	return typed
+}
+
+// watcher establishes a monitor on behalf of a probe then exits.
+type watcher struct {
+	watched  actors.Pid
+	listener actors.Pid
+}
+
+func (w *watcher) OnMessage(r actors.Runtime, m any) {
+	if _, ok := m.(*actors.Start); ok {
+		r.Monitor2(w.watched, w.listener)
+		r.Exit(nil)
+	}
+}
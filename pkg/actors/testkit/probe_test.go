@@ -0,0 +1,39 @@
+package testkit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/meschbach/go-junk-bucket/pkg/actors/local"
+	"github.com/stretchr/testify/assert"
+)
+
+type greeting struct {
+	name string
+}
+
+func TestProbeWithLocalSystem(t *testing.T) {
+	t.Parallel()
+	ctx, done := context.WithCancel(context.Background())
+	t.Cleanup(done)
+	sys := local.NewSystem()
+	probe := NewTestProbe(t, sys)
+	probe.Timeout = time.Second
+	pid := sys.Spawn(ctx, &recorder{target: probe.Pid()})
+	probe.Watch(pid)
+
+	probe.Tell(pid, greeting{name: "probe"})
+	assert.Equal(t, "probe", ExpectMsgType[greeting](probe).name)
+	probe.ExpectNoMsg(10 * time.Millisecond)
+
+	probe.Tell(pid, "bye")
+	probe.ExpectMsg("bye")
+	sys.Tell(ctx, pid, 42)
+	assert.Equal(t, 42, ExpectMsgType[int](probe))
+
+	assert.NoError(t, sys.Shutdown(ctx))
+	exit := probe.ExpectTerminated(pid)
+	assert.Equal(t, actors.Stopping{}, exit.(actors.NormalExit).ExitValue)
+}
@@ -0,0 +1,244 @@
+package testkit
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+)
+
+// runtime is the actors.Runtime given to an actor while handling a single message.
+type runtime struct {
+	cell    *cell
+	ctx     context.Context
+	current envelope
+}
+
+func (r *runtime) system() *System {
+	return r.cell.system
+}
+
+func (r *runtime) Tell(p actors.Pid, m any) {
+	r.system().tell(r.cell.self, p, m)
+}
+
+func (r *runtime) Self() actors.Pid {
+	return r.cell.self
+}
+
+func (r *runtime) Log() actors.Logger {
+	return &logger{who: r.cell.self}
+}
+
+func (r *runtime) Spawn(actor actors.MessageActor, opts ...any) actors.Pid {
+	return r.system().spawn(r.cell, actor, opts...)
+}
+
+func (r *runtime) SpawnPort() actors.Port {
+	return r.system().NewPort()
+}
+
+func (r *runtime) SpawnMonitor(actor actors.MessageActor) actors.Pid {
+	return r.Spawn(actor, actors.MonitorOpt{Tell: r.cell.self})
+}
+
+func (r *runtime) SpawnMailbox() actors.Port {
+	return r.SpawnPort()
+}
+
+func (r *runtime) SpawnLink(actor actors.MessageActor, opts ...any) actors.Pid {
+	return r.Spawn(actor, append(opts, actors.LinkOpt{With: r.cell.self})...)
+}
+
+func (r *runtime) Link(other actors.Pid) {
+	s := r.system()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	r.cell.linkLocked(other)
+}
+
+func (r *runtime) Unlink(other actors.Pid) {
+	s := r.system()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(r.cell.links, other)
+	if target, has := s.cells[other]; has {
+		delete(target.links, r.cell.self)
+	}
+}
+
+func (r *runtime) TrapExits(trap bool) {
+	r.cell.trapExits = trap
+}
+
+func (r *runtime) Monitor2(watched actors.Pid, watcher actors.Pid) {
+	s := r.system()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	target, has := s.cells[watched]
+	if _, port := s.ports[watched]; port {
+		//as with the local system, ports do not notify monitors
+		return
+	}
+	if !has {
+		s.tellLocked(r.cell.self, watcher, actors.NormalExit{Who: watched, ExitValue: actors.NoProcess{}})
+		return
+	}
+	target.monitors = append(target.monitors, monitor{listener: watcher})
+}
+
+func (r *runtime) Unmonitor(watched actors.Pid, watcher actors.Pid) {
+	s := r.system()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if target, has := s.cells[watched]; has {
+		remaining := target.monitors[:0]
+		for _, m := range target.monitors {
+			if m.listener != watcher {
+				remaining = append(remaining, m)
+			}
+		}
+		target.monitors = remaining
+	}
+}
+
+func (r *runtime) Terminate(target actors.Pid) {
+	r.system().enqueue(r.cell.self, target, terminateSignal{})
+}
+
+func (r *runtime) Exit(result any) {
+	r.system().enqueue(r.cell.self, r.cell.self, exitSignal{result: result})
+}
+
+func (r *runtime) SendAfter(delay time.Duration, m any) actors.TimerRef {
+	s := r.system()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.scheduleLocked(r.cell, delay, 0, m)
+}
+
+func (r *runtime) SendInterval(period time.Duration, m any) actors.TimerRef {
+	s := r.system()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.scheduleLocked(r.cell, period, period, m)
+}
+
+func (r *runtime) Register(name string, who actors.Pid) {
+	s := r.system()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	r.cell.names[name] = who
+}
+
+func (r *runtime) Unregister(name string) {
+	s := r.system()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(r.cell.names, name)
+}
+
+func (r *runtime) LookupPath(path string) actors.Pid {
+	s := r.system()
+	s.lock.Lock()
+	from := r.cell
+	if strings.HasPrefix(path, "/") {
+		path = path[1:]
+		for from.parent != nil {
+			from = from.parent
+		}
+	}
+	pid, err := s.resolveLocked(from.self, path)
+	s.lock.Unlock()
+	if err != nil {
+		r.Log().Fatal("%s", err.Error())
+	}
+	return pid
+}
+
+func (r *runtime) NamedRef(name string) string {
+	return "/" + strings.Join(append(r.namedParts(), name), "/")
+}
+
+func (r *runtime) SelfNamedRef() string {
+	return "/" + strings.Join(r.namedParts(), "/")
+}
+
+func (r *runtime) namedParts() []string {
+	s := r.system()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return r.cell.namedParts()
+}
+
+func (r *runtime) Become(handler actors.MessageActor) {
+	if count := len(r.cell.behaviors); count > 1 {
+		r.cell.behaviors[count-1] = handler
+		return
+	}
+	r.cell.behaviors = append(r.cell.behaviors, handler)
+}
+
+func (r *runtime) BecomeStacked(handler actors.MessageActor) {
+	r.cell.behaviors = append(r.cell.behaviors, handler)
+}
+
+func (r *runtime) Unbecome() {
+	if count := len(r.cell.behaviors); count > 1 {
+		r.cell.behaviors[count-1] = nil
+		r.cell.behaviors = r.cell.behaviors[:count-1]
+	}
+}
+
+func (r *runtime) Stash(m any) error {
+	s := r.system()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(r.cell.stash) >= r.cell.stashCapacity {
+		return &actors.StashFullError{Capacity: r.cell.stashCapacity}
+	}
+	//stashed messages retain the sender of the message being processed
+	r.cell.stash = append(r.cell.stash, envelope{to: r.cell.self, sender: r.current.sender, message: m})
+	return nil
+}
+
+func (r *runtime) UnstashAll() {
+	s := r.system()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(r.cell.stash) == 0 {
+		return
+	}
+	s.queue = append(r.cell.stash, s.queue...)
+	r.cell.stash = nil
+}
+
+func (r *runtime) MailboxDepth(who actors.Pid) int {
+	s := r.system()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if p, has := s.ports[who]; has {
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		return len(p.inbox)
+	}
+	if _, has := s.cells[who]; !has {
+		return -1
+	}
+	depth := 0
+	for _, e := range s.queue {
+		if e.to == who && !isSignal(e.message) {
+			depth++
+		}
+	}
+	return depth
+}
+
+func (r *runtime) Defer(fn func(r actors.Runtime)) {
+	r.system().enqueue(r.cell.self, r.cell.self, deferSignal{fn: fn})
+}
+
+func (r *runtime) Context() context.Context {
+	return r.ctx
+}
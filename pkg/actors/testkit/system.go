@@ -0,0 +1,317 @@
+// Package testkit provides tools for testing actors: a TestProbe for asserting on received messages and a
+// deterministic System which only delivers messages as the test steps it.
+package testkit
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+)
+
+// Epoch is the initial time of the virtual clock of each System.
+var Epoch = time.Unix(0, 0).UTC()
+
+// envelope is a message waiting to be delivered to an actor.
+type envelope struct {
+	to      actors.Pid
+	sender  actors.Pid
+	message any
+}
+
+// System is a deterministic implementation of actors.System.  Messages are delivered one at a time, in the order they
+// were sent, only when the test steps the system via Step, RunUntilIdle or Advance.  Timers are driven by a virtual
+// clock which only moves through Advance.
+//
+// Ports step the system when waiting for a message, so blocking operations such as actors.Call work within actors.
+// Goroutines spawned by actors, such as those of actors.AskAsync, may step the system concurrently with the test.
+type System struct {
+	//lock guards all state of the system and the relationships between actors.  It is never held while an actor is
+	//handling a message.
+	lock        sync.Mutex
+	nextID      uint64
+	queue       []envelope
+	cells       map[actors.Pid]*cell
+	ports       map[actors.Pid]*port
+	root        *cell
+	now         time.Time
+	timers      []*timer
+	deadLetters []actors.DeadLetter
+}
+
+// NewSystem creates an empty deterministic system with the virtual clock at Epoch.
+func NewSystem() *System {
+	return &System{
+		cells: make(map[actors.Pid]*cell),
+		ports: make(map[actors.Pid]*port),
+		now:   Epoch,
+	}
+}
+
+func (s *System) nextPID() actors.Pid {
+	s.nextID++
+	return actors.Pid{Process: s.nextID}
+}
+
+// Now is the current time of the virtual clock.
+func (s *System) Now() time.Time {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.now
+}
+
+// Pending is the number of messages waiting to be delivered.
+func (s *System) Pending() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.queue)
+}
+
+// DeadLetters lists the messages which could not be delivered.
+func (s *System) DeadLetters() []actors.DeadLetter {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return slices.Clone(s.deadLetters)
+}
+
+// Step delivers the oldest message whose target is not already handling a message.  Returns false if no message
+// could be delivered.
+func (s *System) Step() bool {
+	s.lock.Lock()
+	index := slices.IndexFunc(s.queue, func(e envelope) bool {
+		c, has := s.cells[e.to]
+		return !has || !c.busy
+	})
+	if index < 0 {
+		s.lock.Unlock()
+		return false
+	}
+	next := s.queue[index]
+	s.queue = slices.Delete(s.queue, index, index+1)
+	c, has := s.cells[next.to]
+	if !has {
+		s.deadLetterLocked(next, actors.DeadLetterTargetDone)
+		s.lock.Unlock()
+		return true
+	}
+	c.busy = true
+	s.lock.Unlock()
+
+	c.deliver(next)
+
+	s.lock.Lock()
+	c.busy = false
+	s.lock.Unlock()
+	return true
+}
+
+// RunUntilIdle steps the system until no messages remain, returning the number of messages delivered.
+func (s *System) RunUntilIdle() int {
+	delivered := 0
+	for s.Step() {
+		delivered++
+	}
+	return delivered
+}
+
+// Advance moves the virtual clock forward by d, firing timers in the order they are due and running the system until
+// idle after each.
+func (s *System) Advance(d time.Duration) {
+	s.advanceUntil(s.Now().Add(d), func() bool { return false })
+}
+
+// advanceUntil moves the virtual clock towards deadline until done is satisfied.  Returns true if done was satisfied.
+func (s *System) advanceUntil(deadline time.Time, done func() bool) bool {
+	for {
+		s.RunUntilIdle()
+		if done() {
+			return true
+		}
+		s.lock.Lock()
+		if !s.fireNextTimerLocked(deadline) {
+			if deadline.After(s.now) {
+				s.now = deadline
+			}
+			s.lock.Unlock()
+			return done()
+		}
+		s.lock.Unlock()
+	}
+}
+
+func (s *System) enqueueLocked(sender, to actors.Pid, message any) {
+	s.queue = append(s.queue, envelope{to: to, sender: sender, message: message})
+}
+
+func (s *System) enqueue(sender, to actors.Pid, message any) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.enqueueLocked(sender, to, message)
+}
+
+func (s *System) deadLetterLocked(e envelope, reason actors.DeadLetterReason) {
+	if isSignal(e.message) {
+		return
+	}
+	s.deadLetters = append(s.deadLetters, actors.DeadLetter{Target: e.to, Sender: e.sender, Message: e.message, Reason: reason})
+}
+
+func (s *System) tell(sender, to actors.Pid, m any) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.tellLocked(sender, to, m)
+}
+
+// Tell enqueues m for delivery to p.  The message is not delivered until the system is stepped.
+func (s *System) Tell(ctx context.Context, p actors.Pid, m any) {
+	s.tell(actors.Pid{}, p, m)
+}
+
+func (s *System) NewPort() actors.Port {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	p := newPort(s, s.nextPID())
+	s.ports[p.self] = p
+	return p
+}
+
+func (s *System) Spawn(ctx context.Context, actor actors.MessageActor, opts ...any) actors.Pid {
+	return s.spawn(nil, actor, opts...)
+}
+
+func (s *System) spawn(parent *cell, actor actors.MessageActor, opts ...any) actors.Pid {
+	var monitors []monitor
+	var links []actors.Pid
+	var registerAs *string
+	stashCapacity := defaultStashCapacity
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case actors.MonitorOpt:
+			monitors = append(monitors, monitor{listener: o.Tell, momento: o.Momento})
+		case *actors.MonitorOpt:
+			monitors = append(monitors, monitor{listener: o.Tell, momento: o.Momento})
+		case actors.RegisterOpt:
+			registerAs = &o.Name
+		case actors.LinkOpt:
+			links = append(links, o.With)
+		case actors.StashOpt:
+			stashCapacity = o.Capacity
+		case actors.MailboxOpt:
+			//mailboxes are unbounded as messages only accumulate until the test steps the system
+		default:
+			panic(fmt.Sprintf("unknown option type %#v", opt))
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	c := &cell{
+		system:        s,
+		self:          s.nextPID(),
+		parent:        parent,
+		behaviors:     []actors.MessageActor{actor},
+		names:         make(map[string]actors.Pid),
+		monitors:      monitors,
+		links:         make(map[actors.Pid]struct{}),
+		children:      make(map[*cell]struct{}),
+		timers:        make(map[*timer]struct{}),
+		stashCapacity: stashCapacity,
+	}
+	s.cells[c.self] = c
+	if s.root == nil {
+		s.root = c
+	}
+	if parent != nil {
+		parent.children[c] = struct{}{}
+		if registerAs != nil {
+			parent.names[*registerAs] = c.self
+		}
+	}
+	for _, with := range links {
+		c.linkLocked(with)
+	}
+	s.enqueueLocked(actors.Pid{}, c.self, &actors.Start{})
+	return c.self
+}
+
+// Lookup resolves the absolute name of an actor from the first actor spawned.  Panics if the name does not exist.
+func (s *System) Lookup(ctx context.Context, absolutePath string) actors.Pid {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.root == nil {
+		panic("no actors have been spawned")
+	}
+	pid, err := s.resolveLocked(s.root.self, strings.TrimPrefix(absolutePath, "/"))
+	if err != nil {
+		panic(err.Error())
+	}
+	return pid
+}
+
+// resolveLocked follows the names of path starting from the actor from.
+func (s *System) resolveLocked(from actors.Pid, path string) (actors.Pid, error) {
+	component := from
+	if path == "" {
+		return component, nil
+	}
+	parts := strings.Split(path, "/")
+	for index, part := range parts {
+		c, has := s.cells[component]
+		if !has {
+			return actors.Pid{}, fmt.Errorf("component %s of path %#v has exited", component, parts)
+		}
+		next, has := c.names[part]
+		if !has {
+			return actors.Pid{}, fmt.Errorf("no such component %q (%d) in path %#v", part, index, parts)
+		}
+		component = next
+	}
+	return component, nil
+}
+
+// Shutdown stops all actors, children before their parents.  Each actor receives actors.Stopping once the messages
+// already sent to it have been delivered.
+func (s *System) Shutdown(ctx context.Context) error {
+	for {
+		s.lock.Lock()
+		var leaves []actors.Pid
+		for _, c := range s.cells {
+			if len(c.children) == 0 {
+				leaves = append(leaves, c.self)
+			}
+		}
+		slices.SortFunc(leaves, func(a, b actors.Pid) int {
+			return cmp.Compare(b.Process, a.Process)
+		})
+		for _, leaf := range leaves {
+			s.enqueueLocked(actors.Pid{}, leaf, stopSignal{})
+		}
+		s.lock.Unlock()
+		if len(leaves) == 0 {
+			return nil
+		}
+		s.RunUntilIdle()
+		if ctx.Err() != nil {
+			return &actors.ShutdownError{Remaining: s.remaining()}
+		}
+	}
+}
+
+// remaining lists the actors which have not exited.
+func (s *System) remaining() []actors.Pid {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	out := make([]actors.Pid, 0, len(s.cells))
+	for pid := range s.cells {
+		out = append(out, pid)
+	}
+	slices.SortFunc(out, func(a, b actors.Pid) int {
+		return cmp.Compare(a.Process, b.Process)
+	})
+	return out
+}
@@ -0,0 +1,246 @@
+package testkit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/meschbach/go-junk-bucket/pkg/actors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder forwards each message it receives to target.
+type recorder struct {
+	target actors.Pid
+}
+
+func (r *recorder) OnMessage(rt actors.Runtime, m any) {
+	switch msg := m.(type) {
+	case *actors.Start, actors.Stopping:
+	case scheduleAfter:
+		rt.SendAfter(msg.delay, msg.message)
+	case scheduleInterval:
+		ref := rt.SendInterval(msg.period, msg.message)
+		rt.Tell(rt.Self(), cancelAfter{ref: ref, count: msg.count})
+	case cancelAfter:
+		rt.Become(&cancellingRecorder{recorder: r, msg: msg})
+	default:
+		rt.Tell(r.target, m)
+	}
+}
+
+type scheduleAfter struct {
+	delay   time.Duration
+	message any
+}
+
+type scheduleInterval struct {
+	period  time.Duration
+	message any
+	count   int
+}
+
+type cancelAfter struct {
+	ref   actors.TimerRef
+	count int
+}
+
+// cancellingRecorder cancels the interval once it has fired count times.
+type cancellingRecorder struct {
+	recorder *recorder
+	msg      cancelAfter
+	seen     int
+}
+
+func (c *cancellingRecorder) OnMessage(rt actors.Runtime, m any) {
+	c.recorder.OnMessage(rt, m)
+	c.seen++
+	if c.seen == c.msg.count {
+		c.msg.ref.Cancel()
+	}
+}
+
+func TestStep(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	sys := NewSystem()
+	probe := NewTestProbe(t, sys)
+	pid := sys.Spawn(ctx, &recorder{target: probe.Pid()})
+	sys.Tell(ctx, pid, "first")
+	sys.Tell(ctx, pid, "second")
+
+	assert.Equal(t, 3, sys.Pending(), "start and both messages are queued")
+	require.True(t, sys.Step(), "start")
+	require.True(t, sys.Step(), "first")
+	assert.Equal(t, 1, sys.Pending())
+	probe.ExpectMsg("first")
+	probe.ExpectMsg("second")
+	assert.Equal(t, 0, sys.Pending())
+	assert.False(t, sys.Step())
+}
+
+func TestVirtualTimers(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	t.Run("SendAfter fires once the clock advances", func(t *testing.T) {
+		t.Parallel()
+		sys := NewSystem()
+		probe := NewTestProbe(t, sys)
+		pid := sys.Spawn(ctx, &recorder{target: probe.Pid()})
+		sys.Tell(ctx, pid, scheduleAfter{delay: time.Minute, message: "late"})
+		sys.Tell(ctx, pid, scheduleAfter{delay: time.Second, message: "early"})
+
+		probe.ExpectNoMsg(time.Second - time.Millisecond)
+		probe.ExpectMsg("early")
+		assert.Equal(t, Epoch.Add(time.Second), sys.Now())
+		probe.ExpectNoMsg(58 * time.Second)
+		sys.Advance(time.Second)
+		probe.ExpectMsg("late")
+		assert.Equal(t, Epoch.Add(time.Minute), sys.Now())
+	})
+
+	t.Run("SendInterval fires until cancelled", func(t *testing.T) {
+		t.Parallel()
+		sys := NewSystem()
+		probe := NewTestProbe(t, sys)
+		pid := sys.Spawn(ctx, &recorder{target: probe.Pid()})
+		sys.Tell(ctx, pid, scheduleInterval{period: time.Second, message: "tick", count: 3})
+
+		sys.Advance(time.Hour)
+		for range 3 {
+			probe.ExpectMsg("tick")
+		}
+		probe.ExpectNoMsg(time.Hour)
+	})
+
+	t.Run("exiting cancels timers", func(t *testing.T) {
+		t.Parallel()
+		sys := NewSystem()
+		probe := NewTestProbe(t, sys)
+		pid := sys.Spawn(ctx, &recorder{target: probe.Pid()})
+		sys.Tell(ctx, pid, scheduleAfter{delay: time.Second, message: "never"})
+		sys.RunUntilIdle()
+		probe.Watch(pid)
+		require.NoError(t, sys.Shutdown(ctx))
+		probe.ExpectTerminated(pid)
+		probe.ExpectNoMsg(time.Minute)
+	})
+}
+
+// panicker panics upon receiving any message after starting.
+type panicker struct{}
+
+func (p *panicker) OnMessage(r actors.Runtime, m any) {
+	if _, ok := m.(*actors.Start); ok {
+		return
+	}
+	panic(m)
+}
+
+func TestTermination(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	t.Run("panic", func(t *testing.T) {
+		t.Parallel()
+		sys := NewSystem()
+		probe := NewTestProbe(t, sys)
+		pid := sys.Spawn(ctx, &panicker{})
+		probe.Watch(pid)
+		sys.RunUntilIdle()
+		sys.Tell(ctx, pid, "boom")
+		sys.Tell(ctx, pid, "lost")
+
+		exit := probe.ExpectTerminated(pid)
+		assert.IsType(t, actors.PanicExit{}, exit)
+		assert.Equal(t, []actors.DeadLetter{{Target: pid, Message: "lost", Reason: actors.DeadLetterTargetDone}}, sys.DeadLetters())
+	})
+
+	t.Run("missing actor", func(t *testing.T) {
+		t.Parallel()
+		sys := NewSystem()
+		probe := NewTestProbe(t, sys)
+		missing := actors.Pid{Process: 1000}
+		probe.Watch(missing)
+		exit := probe.ExpectTerminated(missing)
+		assert.Equal(t, actors.NormalExit{Who: missing, ExitValue: actors.NoProcess{}}, exit)
+
+		probe.Tell(missing, "hello")
+		assert.Equal(t, []actors.DeadLetter{{Target: missing, Sender: probe.Pid(), Message: "hello", Reason: actors.DeadLetterNoTarget}}, sys.DeadLetters())
+	})
+}
+
+// echo replies to ask requests with the value requested.
+type echo struct{}
+
+type echoRequest struct {
+	replyTo actors.ReplyTo
+	value   string
+}
+
+func (e *echo) OnMessage(r actors.Runtime, m any) {
+	if msg, ok := m.(echoRequest); ok {
+		msg.replyTo.Reply(r, msg.value)
+	}
+}
+
+// asker asks target to echo each string it receives, forwarding the reply to result.
+type asker struct {
+	target actors.Pid
+	result actors.Pid
+}
+
+func (a *asker) OnMessage(r actors.Runtime, m any) {
+	if msg, ok := m.(string); ok {
+		value, err := actors.Ask[string](r.Context(), r, a.target, func(replyTo actors.ReplyTo) any {
+			return echoRequest{replyTo: replyTo, value: msg}
+		})
+		if err != nil {
+			r.Tell(a.result, err)
+			return
+		}
+		r.Tell(a.result, value)
+	}
+}
+
+func TestBlockingAsk(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	sys := NewSystem()
+	probe := NewTestProbe(t, sys)
+	target := sys.Spawn(ctx, &echo{})
+	pid := sys.Spawn(ctx, &asker{target: target, result: probe.Pid()})
+	sys.Tell(ctx, pid, "echo")
+	probe.ExpectMsg("echo")
+}
+
+// stopRecorder reports Stopping to target.
+type stopRecorder struct {
+	name   string
+	target actors.Pid
+	child  actors.MessageActor
+}
+
+func (s *stopRecorder) OnMessage(r actors.Runtime, m any) {
+	switch m.(type) {
+	case *actors.Start:
+		if s.child != nil {
+			r.Spawn(s.child)
+		}
+	case actors.Stopping:
+		r.Tell(s.target, s.name)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	sys := NewSystem()
+	probe := NewTestProbe(t, sys)
+	sys.Spawn(ctx, &stopRecorder{name: "parent", target: probe.Pid(), child: &stopRecorder{name: "child", target: probe.Pid()}})
+	sys.RunUntilIdle()
+
+	require.NoError(t, sys.Shutdown(ctx))
+	probe.ExpectMsg("child")
+	probe.ExpectMsg("parent")
+}
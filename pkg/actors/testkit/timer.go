@@ -0,0 +1,95 @@
+package testkit
+
+import (
+	"slices"
+	"time"
+)
+
+type timerState uint8
+
+const (
+	timerActive timerState = iota
+	timerDelivered
+	timerCancelled
+)
+
+// timer is a message scheduled against the virtual clock of the System.
+type timer struct {
+	system  *System
+	owner   *cell
+	message any
+	//period is zero for timers which fire once
+	period time.Duration
+	due    time.Time
+	state  timerState
+}
+
+func (t *timer) Cancel() bool {
+	t.system.lock.Lock()
+	defer t.system.lock.Unlock()
+	return t.cancelLocked()
+}
+
+func (t *timer) cancelLocked() bool {
+	if t.state != timerActive {
+		return false
+	}
+	t.state = timerCancelled
+	delete(t.owner.timers, t)
+	t.system.timers = slices.DeleteFunc(t.system.timers, func(other *timer) bool { return other == t })
+	return true
+}
+
+// claim is invoked upon delivery of the timer's signal, returning false if the timer was cancelled since firing.
+func (t *timer) claim() bool {
+	t.system.lock.Lock()
+	defer t.system.lock.Unlock()
+	switch t.state {
+	case timerCancelled:
+		return false
+	case timerActive:
+		if t.period == 0 {
+			t.state = timerDelivered
+			delete(t.owner.timers, t)
+		}
+	}
+	return true
+}
+
+// scheduleLocked registers a timer for owner due after delay.
+func (s *System) scheduleLocked(owner *cell, delay, period time.Duration, m any) *timer {
+	t := &timer{system: s, owner: owner, message: m, period: period, due: s.now.Add(delay)}
+	owner.timers[t] = struct{}{}
+	s.timers = append(s.timers, t)
+	return t
+}
+
+// fireNextTimerLocked moves the clock to the earliest timer due no later than deadline and enqueues its message.
+// Timers due at the same time fire in the order they were scheduled.  Returns false if no timer is due.
+func (s *System) fireNextTimerLocked(deadline time.Time) bool {
+	var next *timer
+	for _, t := range s.timers {
+		if t.due.After(deadline) {
+			continue
+		}
+		if next == nil || t.due.Before(next.due) {
+			next = t
+		}
+	}
+	if next == nil {
+		return false
+	}
+	if next.due.After(s.now) {
+		s.now = next.due
+	}
+	s.enqueueLocked(next.owner.self, next.owner.self, timerSignal{timer: next})
+	if next.period > 0 {
+		next.due = next.due.Add(next.period)
+		//keep timers due at the same time in order of scheduling
+		s.timers = slices.DeleteFunc(s.timers, func(other *timer) bool { return other == next })
+		s.timers = append(s.timers, next)
+	} else {
+		s.timers = slices.DeleteFunc(s.timers, func(other *timer) bool { return other == next })
+	}
+	return true
+}
@@ -0,0 +1,8 @@
+package actors
+
+// TimerRef is a handle to a message scheduled through Runtime.SendAfter or Runtime.SendInterval.
+type TimerRef interface {
+	//Cancel prevents any further deliveries of the scheduled message.  Returns true if a delivery was prevented, false
+	//if the timer has already fired or was previously cancelled.  Safe to call from any goroutine.
+	Cancel() bool
+}
@@ -0,0 +1,70 @@
+package actors
+
+import "context"
+
+// TypedActor receives messages of type M, allowing the compiler to check messages sent through a Ref.
+type TypedActor[M any] interface {
+	OnMessage(r Runtime, m M)
+}
+
+// SignalHandler may be implemented by a TypedActor to receive messages which are not of the actor's message type,
+// such as Start, NormalExit, PanicExit, and LinkExit.
+type SignalHandler interface {
+	OnSignal(r Runtime, m any)
+}
+
+type typedAdapter[M any] struct {
+	actor TypedActor[M]
+}
+
+func (t *typedAdapter[M]) OnMessage(r Runtime, m any) {
+	switch msg := m.(type) {
+	case M:
+		t.actor.OnMessage(r, msg)
+	default:
+		if handler, ok := t.actor.(SignalHandler); ok {
+			handler.OnSignal(r, m)
+		} else if _, start := m.(*Start); !start {
+			r.Log().Warn("unexpected message for typed actor: %#v", m)
+		}
+	}
+}
+
+// Typed adapts actor to a MessageActor so it may be spawned through the untyped API.
+func Typed[M any](actor TypedActor[M]) MessageActor {
+	return &typedAdapter[M]{actor: actor}
+}
+
+// Ref is a Pid known to accept messages of type M.
+type Ref[M any] struct {
+	Pid Pid
+}
+
+// RefOf asserts the actor at pid accepts messages of type M.
+func RefOf[M any](pid Pid) Ref[M] {
+	return Ref[M]{Pid: pid}
+}
+
+// Tell sends m to the referenced actor.
+func (r Ref[M]) Tell(from Ingestor, m M) {
+	from.Tell(r.Pid, m)
+}
+
+// TellFrom sends m to the referenced actor from outside of the actor system.
+func (r Ref[M]) TellFrom(ctx context.Context, sys System, m M) {
+	sys.Tell(ctx, r.Pid, m)
+}
+
+func (r Ref[M]) String() string {
+	return r.Pid.String()
+}
+
+// SpawnTyped spawns actor as a child of the executing actor.
+func SpawnTyped[M any](r Runtime, actor TypedActor[M], opts ...any) Ref[M] {
+	return RefOf[M](r.Spawn(Typed(actor), opts...))
+}
+
+// SpawnTypedOn spawns actor on the given system.
+func SpawnTypedOn[M any](ctx context.Context, sys System, actor TypedActor[M], opts ...any) Ref[M] {
+	return RefOf[M](sys.Spawn(ctx, Typed(actor), opts...))
+}
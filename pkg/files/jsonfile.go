@@ -2,8 +2,10 @@ package files
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 )
 
 type FileContentsError struct {
@@ -32,3 +34,31 @@ func ParseJSONFile(fileName string, out interface{}) error {
 	}
 	return nil
 }
+
+// WriteJSONFile replaces the contents of fileName with the JSON encoding of value.  The contents are written to a
+// temporary file within the same directory then renamed, so readers never observe a partially written file.
+func WriteJSONFile(fileName string, value interface{}) error {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return &FileContentsError{FileName: fileName, Underlying: err}
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(fileName), filepath.Base(fileName)+".*.tmp")
+	if err != nil {
+		return &FileContentsError{FileName: fileName, Underlying: err}
+	}
+	_, writeErr := temp.Write(bytes)
+	if writeErr == nil {
+		writeErr = temp.Sync()
+	}
+	closeErr := temp.Close()
+	if err := errors.Join(writeErr, closeErr); err != nil {
+		_ = os.Remove(temp.Name())
+		return &FileContentsError{FileName: fileName, Underlying: err}
+	}
+	if err := os.Rename(temp.Name(), fileName); err != nil {
+		_ = os.Remove(temp.Name())
+		return &FileContentsError{FileName: fileName, Underlying: err}
+	}
+	return nil
+}
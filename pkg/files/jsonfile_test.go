@@ -1,6 +1,7 @@
 package files
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -19,3 +20,19 @@ func TestParseJSONFile(t *testing.T) {
 
 	assert.Equal(t, "an example value", example.KeyA)
 }
+
+func TestWriteJSONFile(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "example.json")
+	if err := WriteJSONFile(fileName, ExampleJSONFile{KeyA: "first"}); err != nil {
+		panic(err)
+	}
+	if err := WriteJSONFile(fileName, ExampleJSONFile{KeyA: "replaced"}); err != nil {
+		panic(err)
+	}
+
+	var example ExampleJSONFile
+	if err := ParseJSONFile(fileName, &example); err != nil {
+		panic(err)
+	}
+	assert.Equal(t, "replaced", example.KeyA)
+}
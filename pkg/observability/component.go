@@ -4,19 +4,25 @@ import (
 	"context"
 	"errors"
 
+	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/trace"
 )
 
 type Component struct {
-	otelAnchor *trace.TracerProvider
+	otelAnchor  *trace.TracerProvider
+	meterAnchor *metric.MeterProvider
 }
 
 func (c *Component) ShutdownGracefully(ctx context.Context) error {
 	if c == nil {
 		return nil
 	}
+	var problems []error
 	if err := c.otelAnchor.Shutdown(ctx); err != nil {
-		return errors.Join(errors.New("otel shutdown"), err)
+		problems = append(problems, errors.Join(errors.New("otel shutdown"), err))
 	}
-	return nil
+	if err := c.meterAnchor.Shutdown(ctx); err != nil {
+		problems = append(problems, errors.Join(errors.New("otel metrics shutdown"), err))
+	}
+	return errors.Join(problems...)
 }
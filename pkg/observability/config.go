@@ -7,13 +7,17 @@ import (
 
 	"github.com/meschbach/go-junk-bucket/pkg"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
 	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
 	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/trace"
 )
 
-// Config is used to describe the setup of the observability libraries
+// Config is used to describe the setup of the observability libraries.  Start installs the global providers for both
+// traces and metrics, exporting each through the configured exporter.
 type Config struct {
 	//Exporter is the name of the OTEL exporter to utilize.  Currently supported values are `none` and `jaeger`.
 	Exporter string `json:"exporter"`
@@ -44,10 +48,14 @@ func (c Config) Start(setup context.Context) (*Component, error) {
 		fmt.Printf("Tracing %#v\n", c)
 	}
 	var exp trace.SpanExporter
+	var metricExp metric.Exporter
 	var err error
 	switch c.Exporter {
 	case "stdout":
 		exp, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err == nil {
+			metricExp, err = stdoutmetric.New(stdoutmetric.WithPrettyPrint())
+		}
 	case "jaeger":
 		return nil, errors.New("Jaeger exporter is deprecated, switch to grpc.")
 	case "grpc":
@@ -55,6 +63,10 @@ func (c Config) Start(setup context.Context) (*Component, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to create trace exporter: %w", err)
 		}
+		metricExp, err = otlpmetricgrpc.New(setup)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create metric exporter: %w", err)
+		}
 	case "none":
 		if !c.Silent {
 			fmt.Println("No exporter configured.  Not recording spans.")
@@ -74,12 +86,18 @@ func (c Config) Start(setup context.Context) (*Component, error) {
 		sendingOption = trace.WithSyncer(exp)
 	}
 
+	res := newResource(c)
 	tp := trace.NewTracerProvider(
 		sendingOption,
-		trace.WithResource(newResource(c)),
+		trace.WithResource(res),
 	)
 	otel.SetTracerProvider(tp)
-	return &Component{otelAnchor: tp}, nil
+	mp := metric.NewMeterProvider(
+		metric.WithReader(metric.NewPeriodicReader(metricExp)),
+		metric.WithResource(res),
+	)
+	otel.SetMeterProvider(mp)
+	return &Component{otelAnchor: tp, meterAnchor: mp}, nil
 }
 
 type UnknownExportError struct {